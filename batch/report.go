@@ -0,0 +1,184 @@
+package batch
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/jedynykaban/testkeyholder/model"
+)
+
+// Report formats
+const (
+	FormatText  = "text"
+	FormatJSON  = "json"
+	FormatJUnit = "junit"
+)
+
+// ItemResult holds the validation result of a single item
+type ItemResult struct {
+	Name   string                   `json:"name"`
+	Valid  bool                     `json:"valid"`
+	Errors []*model.ValidationError `json:"errors,omitempty"`
+}
+
+// Summary holds the totals of the report
+type Summary struct {
+	Total  int `json:"total"`
+	Passed int `json:"passed"`
+	Failed int `json:"failed"`
+	// ErrorCounts holds number of problems per validation code
+	ErrorCounts map[string]int `json:"errorCounts"`
+}
+
+// Report is the result of a batch validation
+type Report struct {
+	Items   []ItemResult `json:"items"`
+	Summary Summary      `json:"summary"`
+}
+
+func newReport() *Report {
+	return &Report{Summary: Summary{ErrorCounts: make(map[string]int)}}
+}
+
+func (r *Report) add(res ItemResult) {
+	r.Items = append(r.Items, res)
+	r.Summary.Total++
+	if res.Valid {
+		r.Summary.Passed++
+	} else {
+		r.Summary.Failed++
+	}
+	for _, e := range res.Errors {
+		r.Summary.ErrorCounts[e.Code]++
+	}
+}
+
+// Passed tells whether all the items are valid
+func (r *Report) Passed() bool {
+	return r.Summary.Failed == 0
+}
+
+// Write writes the report in the given format
+func (r *Report) Write(w io.Writer, format string) error {
+	switch format {
+	case FormatText:
+		return r.WriteText(w)
+	case FormatJSON:
+		return r.WriteJSON(w)
+	case FormatJUnit:
+		return r.WriteJUnit(w)
+	default:
+		return fmt.Errorf("Unsupported report format %s, want = %s, %s or %s", format, FormatText, FormatJSON, FormatJUnit)
+	}
+}
+
+// WriteText writes human readable report, valid items without warnings are not listed
+func (r *Report) WriteText(w io.Writer) error {
+	for _, res := range r.Items {
+		if res.Valid && len(res.Errors) == 0 {
+			continue
+		}
+		status := "INVALID"
+		if res.Valid {
+			status = "OK"
+		}
+		fmt.Fprintf(w, "%s: %s\n", res.Name, status)
+		for _, e := range res.Errors {
+			fmt.Fprintf(w, "  %s %s [%s] %s\n", e.Severity, e.Path, e.Code, e.Message)
+		}
+	}
+	var codes []string
+	for code := range r.Summary.ErrorCounts {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	for _, code := range codes {
+		fmt.Fprintf(w, "%-20s %d\n", code, r.Summary.ErrorCounts[code])
+	}
+	result := "PASS"
+	if !r.Passed() {
+		result = "FAIL"
+	}
+	_, err := fmt.Fprintf(w, "%s: %d mitems, %d passed, %d failed\n", result, r.Summary.Total, r.Summary.Passed, r.Summary.Failed)
+	return err
+}
+
+// WriteJSON writes the report as JSON document
+func (r *Report) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// WriteJUnit writes the report as JUnit XML, each item is a test case
+func (r *Report) WriteJUnit(w io.Writer) error {
+	suite := junitTestSuite{Name: "mitem validation", Tests: r.Summary.Total, Failures: r.Summary.Failed}
+	for _, res := range r.Items {
+		tc := junitTestCase{Name: res.Name, ClassName: "mitem"}
+		var lines []string
+		for _, e := range res.Errors {
+			lines = append(lines, fmt.Sprintf("%s %s [%s] %s", e.Severity, e.Path, e.Code, e.Message))
+		}
+		if !res.Valid {
+			tc.Failure = &junitFailure{
+				Message: fmt.Sprintf("%d validation problems", len(res.Errors)),
+				Type:    firstErrorCode(res.Errors),
+				Text:    strings.Join(lines, "\n"),
+			}
+		} else if len(lines) > 0 {
+			tc.SystemOut = strings.Join(lines, "\n")
+		}
+		suite.Cases = append(suite.Cases, tc)
+	}
+	doc := junitTestSuites{Tests: suite.Tests, Failures: suite.Failures, Suites: []junitTestSuite{suite}}
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func firstErrorCode(errs []*model.ValidationError) string {
+	for _, e := range errs {
+		if e.Severity == model.SeverityError {
+			return e.Code
+		}
+	}
+	return ""
+}
@@ -0,0 +1,125 @@
+package batch
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Item is a single mitem read from a source
+type Item struct {
+	// Name identifies the item in the report i.e. file.jsonl:12
+	Name string
+	Data json.RawMessage
+	// Err is set when the item could not be read
+	Err error
+}
+
+// Read streams items from the paths:
+//   - "-" reads stdin as a stream of JSON documents (JSONL or concatenated documents)
+//   - a directory is walked recursively for *.json and *.jsonl files
+//   - a *.jsonl file is read line by line, one mitem per line
+//   - any other file is a single JSON mitem
+//
+// The returned channel is closed once all the paths are read.
+func Read(paths []string) <-chan Item {
+	out := make(chan Item)
+	go func() {
+		defer close(out)
+		for _, path := range paths {
+			readPath(path, out)
+		}
+	}()
+	return out
+}
+
+func readPath(path string, out chan<- Item) {
+	if path == "-" {
+		readStream(os.Stdin, "stdin", out)
+		return
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		out <- Item{Name: path, Err: err}
+		return
+	}
+	if !info.IsDir() {
+		readFile(path, out)
+		return
+	}
+	err = filepath.Walk(path, func(p string, fi os.FileInfo, err error) error {
+		if err != nil {
+			out <- Item{Name: p, Err: err}
+			return nil
+		}
+		ext := strings.ToLower(filepath.Ext(p))
+		if !fi.IsDir() && (ext == ".json" || ext == ".jsonl") {
+			readFile(p, out)
+		}
+		return nil
+	})
+	if err != nil {
+		out <- Item{Name: path, Err: err}
+	}
+}
+
+func readFile(path string, out chan<- Item) {
+	f, err := os.Open(path)
+	if err != nil {
+		out <- Item{Name: path, Err: err}
+		return
+	}
+	defer f.Close()
+	if strings.EqualFold(filepath.Ext(path), ".jsonl") {
+		ReadJSONL(f, path, out)
+		return
+	}
+	var buf bytes.Buffer
+	if _, err := buf.ReadFrom(f); err != nil {
+		out <- Item{Name: path, Err: err}
+		return
+	}
+	out <- Item{Name: path, Data: buf.Bytes()}
+}
+
+// ReadJSONL reads one mitem per line, empty lines are skipped.
+// Items are named after the source and the line number.
+func ReadJSONL(r io.Reader, name string, out chan<- Item) {
+	reader := bufio.NewReader(r)
+	for lineNo := 1; ; lineNo++ {
+		line, err := reader.ReadBytes('\n')
+		if trimmed := bytes.TrimSpace(line); len(trimmed) > 0 {
+			out <- Item{Name: fmt.Sprintf("%s:%d", name, lineNo), Data: trimmed}
+		}
+		if err == io.EOF {
+			return
+		}
+		if err != nil {
+			out <- Item{Name: fmt.Sprintf("%s:%d", name, lineNo), Err: err}
+			return
+		}
+	}
+}
+
+// readStream reads consecutive JSON documents, thus both JSONL and pretty printed documents work.
+// The stream cannot be resynchronised, so reading stops on the first malformed document.
+func readStream(r io.Reader, name string, out chan<- Item) {
+	dec := json.NewDecoder(r)
+	for idx := 1; ; idx++ {
+		var data json.RawMessage
+		err := dec.Decode(&data)
+		if err == io.EOF {
+			return
+		}
+		if err != nil {
+			out <- Item{Name: fmt.Sprintf("%s#%d", name, idx), Err: err}
+			return
+		}
+		out <- Item{Name: fmt.Sprintf("%s#%d", name, idx), Data: data}
+	}
+}
@@ -0,0 +1,102 @@
+// Package batch validates many mitems at once and reports the results.
+package batch
+
+import (
+	"encoding/json"
+	"runtime"
+	"sort"
+	"sync"
+
+	"github.com/jedynykaban/testkeyholder/model"
+	"github.com/jedynykaban/testkeyholder/services"
+)
+
+// Validator validates items concurrently with a pool of workers
+type Validator struct {
+	check   func(data json.RawMessage) []error
+	workers int
+}
+
+// NewValidator - ctor like function - creates a validator running the given number of workers,
+// workers <= 0 means one worker per CPU
+func NewValidator(kojo services.Kojo, workers int) *Validator {
+	return newValidator(kojo.Validate, workers)
+}
+
+// NewSchemaValidator - ctor like function - creates a validator checking the items against the JSON Schema,
+// see services.Kojo.ValidateSchema
+func NewSchemaValidator(kojo services.Kojo, workers int) *Validator {
+	return newValidator(kojo.ValidateSchema, workers)
+}
+
+func newValidator(check func(data json.RawMessage) []error, workers int) *Validator {
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	return &Validator{check: check, workers: workers}
+}
+
+type indexedItem struct {
+	idx int
+	Item
+}
+
+type indexedResult struct {
+	idx int
+	ItemResult
+}
+
+// Run validates all the items and builds the report.
+// Results in the report keep the order the items were read in.
+func (v *Validator) Run(items <-chan Item) *Report {
+	in := make(chan indexedItem)
+	out := make(chan indexedResult)
+
+	var wg sync.WaitGroup
+	for i := 0; i < v.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for item := range in {
+				out <- indexedResult{item.idx, v.validate(item.Item)}
+			}
+		}()
+	}
+	go func() {
+		idx := 0
+		for item := range items {
+			in <- indexedItem{idx, item}
+			idx++
+		}
+		close(in)
+		wg.Wait()
+		close(out)
+	}()
+
+	var results []indexedResult
+	for res := range out {
+		results = append(results, res)
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].idx < results[j].idx })
+
+	report := newReport()
+	for _, res := range results {
+		report.add(res.ItemResult)
+	}
+	return report
+}
+
+func (v *Validator) validate(item Item) ItemResult {
+	ret := ItemResult{Name: item.Name}
+	var errs []error
+	if item.Err != nil {
+		errs = []error{model.NewValidationError("", model.CodeMalformed, nil, "Unable to read mitem: "+item.Err.Error())}
+	} else {
+		errs = v.check(item.Data)
+	}
+	ret.Valid = !model.HasErrors(errs)
+	for _, err := range errs {
+		ret.Errors = append(ret.Errors, model.AsValidationError(err))
+	}
+	return ret
+}
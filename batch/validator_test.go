@@ -0,0 +1,147 @@
+package batch
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jedynykaban/testkeyholder/model"
+	"github.com/jedynykaban/testkeyholder/services"
+)
+
+const (
+	validMitem   = `{"sourceURL": "https://www.svt.se/nyheter/a", "date": "2020-01-02T10:00:00Z", "type": "article", "licensetype": "editorial", "mainimage": {"source": "https://www.svt.se/a.jpg"}, "headline": "Headline", "body": [{"type": "paragraph", "content": "text"}]}`
+	invalidMitem = `{"sourceURL": "https://www.svt.se/nyheter/b", "date": "2020-01-02T10:00:00Z", "type": "article", "licensetype": "editorial", "mainimage": {"source": "https://www.svt.se/b.jpg"}, "body": [{"type": "paragraph", "content": "text"}]}`
+)
+
+// writeSources makes the directory holding a JSONL file and a single mitem file
+func writeSources(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "batch")
+	if err != nil {
+		t.Fatalf("Unable to create temp dir, error = %v", err)
+	}
+	files := map[string]string{
+		"items.jsonl": validMitem + "\n\n" + invalidMitem + "\n" + `{"sourceURL": ` + "\n",
+		"single.json": validMitem,
+		"ignored.txt": "not a mitem",
+		"sub/b.jsonl": invalidMitem,
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Unable to create dir, error = %v", err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Unable to write %s, error = %v", name, err)
+		}
+	}
+	return dir
+}
+
+func TestRead(t *testing.T) {
+	dir := writeSources(t)
+	defer os.RemoveAll(dir)
+
+	var names []string
+	for item := range Read([]string{dir, filepath.Join(dir, "missing.json")}) {
+		name := strings.TrimPrefix(item.Name, dir+string(filepath.Separator))
+		if item.Err != nil {
+			name += " error"
+		}
+		names = append(names, name)
+	}
+	want := []string{"items.jsonl:1", "items.jsonl:3", "items.jsonl:4", "single.json", "sub/b.jsonl:1", "missing.json error"}
+	if strings.Join(names, ",") != strings.Join(want, ",") {
+		t.Errorf("Read got = %v, want = %v", names, want)
+	}
+}
+
+func TestReadStream(t *testing.T) {
+	out := make(chan Item)
+	go func() {
+		defer close(out)
+		readStream(strings.NewReader(validMitem+"\n{\n  \"pretty\": true\n}\n{broken"), "stdin", out)
+	}()
+	var names []string
+	for item := range out {
+		name := item.Name
+		if item.Err != nil {
+			name += " error"
+		}
+		names = append(names, name)
+	}
+	want := []string{"stdin#1", "stdin#2", "stdin#3 error"}
+	if strings.Join(names, ",") != strings.Join(want, ",") {
+		t.Errorf("readStream got = %v, want = %v", names, want)
+	}
+}
+
+func TestValidatorRun(t *testing.T) {
+	dir := writeSources(t)
+	defer os.RemoveAll(dir)
+
+	report := NewValidator(services.NewKojo(), 3).Run(Read([]string{dir}))
+	if got, want := report.Summary, (Summary{Total: 5, Passed: 2, Failed: 3}); got.Total != want.Total || got.Passed != want.Passed || got.Failed != want.Failed {
+		t.Errorf("Run summary got = %+v, want = %+v", got, want)
+	}
+	if report.Passed() {
+		t.Errorf("Passed of report with invalid items got = true")
+	}
+	if report.Summary.ErrorCounts[model.CodeRequired] != 2 || report.Summary.ErrorCounts[model.CodeMalformed] != 1 {
+		t.Errorf("Run error counts got = %v", report.Summary.ErrorCounts)
+	}
+	// results keep the order the items were read in, whatever worker validated them
+	valid := []bool{true, false, false, true, false}
+	for i, res := range report.Items {
+		if res.Valid != valid[i] {
+			t.Errorf("%s valid got = %t, want = %t", res.Name, res.Valid, valid[i])
+		}
+	}
+}
+
+func TestReportWrite(t *testing.T) {
+	dir := writeSources(t)
+	defer os.RemoveAll(dir)
+	report := NewValidator(services.NewKojo(), 2).Run(Read([]string{dir}))
+
+	var text bytes.Buffer
+	if err := report.Write(&text, FormatText); err != nil {
+		t.Fatalf("Write text unexpected error = %v", err)
+	}
+	if !strings.Contains(text.String(), "FAIL: 5 mitems, 2 passed, 3 failed") || strings.Contains(text.String(), "single.json") {
+		t.Errorf("Write text got:\n%s", text.String())
+	}
+
+	var js bytes.Buffer
+	if err := report.Write(&js, FormatJSON); err != nil {
+		t.Fatalf("Write json unexpected error = %v", err)
+	}
+	var decoded Report
+	if err := json.Unmarshal(js.Bytes(), &decoded); err != nil {
+		t.Fatalf("Unable to unmarshal json report, error = %v", err)
+	}
+	if decoded.Summary.Total != 5 || len(decoded.Items) != 5 {
+		t.Errorf("Write json got = %s", js.String())
+	}
+
+	var junit bytes.Buffer
+	if err := report.Write(&junit, FormatJUnit); err != nil {
+		t.Fatalf("Write junit unexpected error = %v", err)
+	}
+	var suites junitTestSuites
+	if err := xml.Unmarshal(junit.Bytes(), &suites); err != nil {
+		t.Fatalf("Unable to unmarshal junit report, error = %v", err)
+	}
+	if suites.Tests != 5 || suites.Failures != 3 || len(suites.Suites) != 1 || len(suites.Suites[0].Cases) != 5 {
+		t.Errorf("Write junit got = %s", junit.String())
+	}
+
+	if err := report.Write(&text, "yaml"); err == nil {
+		t.Errorf("Write of unsupported format expected error")
+	}
+}
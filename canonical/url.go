@@ -0,0 +1,227 @@
+// Package canonical makes canonical forms of the mitems' source URLs,
+// so the same article imported through different links can be matched.
+package canonical
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// trackingPrefixes are the prefixes of the parameters always stripped
+var trackingPrefixes = []string{"utm_"}
+
+// DefaultTrackingParams are the tracking parameters stripped by the canonicalisers created by New
+var DefaultTrackingParams = []string{"fbclid", "gclid", "dclid", "msclkid", "igshid", "mc_cid", "mc_eid", "_ga"}
+
+// wordpressParams identify WordPress permalinks of the ?p=123 style
+var wordpressParams = []string{"p", "page_id"}
+
+var defaultPorts = map[string]string{
+	"http":  "80",
+	"https": "443",
+}
+
+// Canonicalizer makes canonical URLs, the list of stripped tracking parameters is configurable.
+// It also knows the primary domains of the publishers the mitems created inside mosaiq link to.
+type Canonicalizer struct {
+	mu             sync.RWMutex
+	trackingParams map[string]bool
+	// sourceURL host -> primary domain
+	primaryDomains map[string]string
+}
+
+// Default is the canonicaliser used by the services unless configured otherwise
+var Default = New()
+
+// New - ctor like function - creates a canonicaliser stripping DefaultTrackingParams and the given parameters
+func New(trackingParams ...string) *Canonicalizer {
+	c := &Canonicalizer{trackingParams: make(map[string]bool), primaryDomains: make(map[string]string)}
+	c.AddTrackingParams(DefaultTrackingParams...)
+	c.AddTrackingParams(trackingParams...)
+	return c
+}
+
+// AddTrackingParams adds parameters to be stripped, names are case insensitive
+func (c *Canonicalizer) AddTrackingParams(params ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, param := range params {
+		c.trackingParams[strings.ToLower(param)] = true
+	}
+}
+
+// TrackingParams returns sorted names of the stripped parameters, utm_* excluded
+func (c *Canonicalizer) TrackingParams() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	ret := make([]string, 0, len(c.trackingParams))
+	for param := range c.trackingParams {
+		ret = append(ret, param)
+	}
+	sort.Strings(ret)
+	return ret
+}
+
+// SetPrimaryDomain sets the primary domain of the publisher, the key is the host of the publisher's sourceURLs
+// without the www. prefix. The subdomains of the host use the same primary domain unless set otherwise.
+func (c *Canonicalizer) SetPrimaryDomain(host, domain string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.primaryDomains[strings.TrimPrefix(strings.ToLower(host), "www.")] = canonicalDomain(domain)
+}
+
+// LoadPrimaryDomains sets the primary domains read from config, the map is keyed by host
+func (c *Canonicalizer) LoadPrimaryDomains(domains map[string]string) {
+	for host, domain := range domains {
+		c.SetPrimaryDomain(host, domain)
+	}
+}
+
+// PrimaryDomain returns the primary domain of the publisher of the sourceURL, empty when none is set
+func (c *Canonicalizer) PrimaryDomain(sourceURL string) string {
+	u, err := url.Parse(strings.TrimSpace(sourceURL))
+	if err != nil {
+		return ""
+	}
+	host := strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	for labels := strings.Split(host, "."); len(labels) > 1; labels = labels[1:] {
+		if domain, ok := c.primaryDomains[strings.Join(labels, ".")]; ok {
+			return domain
+		}
+	}
+	return ""
+}
+
+// Canonicalize makes the canonical form of the URL:
+// the scheme and host are lowercased, the default port, the fragment and the tracking
+// parameters are dropped, the remaining parameters are sorted and the trailing slash is removed.
+// WordPress permalinks (?p=123) keep the p parameter only and, when primaryDomain is given,
+// the host is replaced with it (see model.MosaiqPrimary).
+func (c *Canonicalizer) Canonicalize(rawURL string, primaryDomain string) (string, error) {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil {
+		return "", fmt.Errorf("Unable to parse URL %s, error = %s", rawURL, err.Error())
+	}
+	u.Scheme = strings.ToLower(u.Scheme)
+	if (u.Scheme != "http" && u.Scheme != "https") || len(u.Host) == 0 {
+		return "", fmt.Errorf("Unable to canonicalise URL %s, absolute http(s) URL expected", rawURL)
+	}
+	u.Host = canonicalHost(u.Scheme, u.Host)
+	u.User = nil
+	u.Fragment = ""
+	u.RawPath = ""
+	if len(u.Path) > 1 {
+		u.Path = strings.TrimRight(u.Path, "/")
+	}
+	if len(u.Path) == 0 {
+		u.Path = "/"
+	}
+
+	query := u.Query()
+	if param, ok := wordpressPermalink(u.Path, query); ok {
+		u.RawQuery = url.Values{param: {query.Get(param)}}.Encode()
+		if domain := canonicalDomain(primaryDomain); len(domain) > 0 {
+			u.Host = domain
+		}
+		return u.String(), nil
+	}
+	for name := range query {
+		if c.isTracking(name) {
+			query.Del(name)
+		}
+	}
+	// Encode sorts the parameters by name
+	u.RawQuery = query.Encode()
+	return u.String(), nil
+}
+
+// Key makes the dedup key of the URL: hex encoded SHA-1 of the canonical URL without the scheme
+// and the www. prefix, so http and https links to the same article share the key
+func (c *Canonicalizer) Key(rawURL string, primaryDomain string) (string, error) {
+	canonical, err := c.Canonicalize(rawURL, primaryDomain)
+	if err != nil {
+		return "", err
+	}
+	return Hash(canonical), nil
+}
+
+// Canonicalize makes the canonical form of the URL with the Default canonicaliser
+func Canonicalize(rawURL string, primaryDomain string) (string, error) {
+	return Default.Canonicalize(rawURL, primaryDomain)
+}
+
+// Key makes the dedup key of the URL with the Default canonicaliser
+func Key(rawURL string, primaryDomain string) (string, error) {
+	return Default.Key(rawURL, primaryDomain)
+}
+
+// Hash makes the dedup key of an already canonical URL.
+// Neither the scheme nor the www. prefix of the host are part of the key.
+func Hash(canonicalURL string) string {
+	if idx := strings.Index(canonicalURL, "://"); idx >= 0 {
+		canonicalURL = canonicalURL[idx+3:]
+	}
+	canonicalURL = strings.TrimPrefix(canonicalURL, "www.")
+	sum := sha1.Sum([]byte(canonicalURL))
+	return hex.EncodeToString(sum[:])
+}
+
+func (c *Canonicalizer) isTracking(param string) bool {
+	param = strings.ToLower(param)
+	for _, prefix := range trackingPrefixes {
+		if strings.HasPrefix(param, prefix) {
+			return true
+		}
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.trackingParams[param]
+}
+
+// canonicalHost lowercases the host and drops the scheme's default port
+func canonicalHost(scheme, host string) string {
+	host = strings.ToLower(host)
+	h, port, err := net.SplitHostPort(host)
+	if err != nil {
+		return strings.TrimSuffix(host, ":")
+	}
+	if port == defaultPorts[scheme] || len(port) == 0 {
+		if strings.Contains(h, ":") {
+			return "[" + h + "]"
+		}
+		return h
+	}
+	return host
+}
+
+// canonicalDomain accepts the domain either bare (skonahem.com) or as URL (http://skonahem.com/)
+func canonicalDomain(domain string) string {
+	domain = strings.TrimSpace(domain)
+	if strings.Contains(domain, "://") {
+		if u, err := url.Parse(domain); err == nil {
+			return canonicalHost(strings.ToLower(u.Scheme), u.Host)
+		}
+	}
+	return strings.ToLower(strings.TrimRight(domain, "/"))
+}
+
+// wordpressPermalink tells whether the URL is a WordPress permalink and which parameter identifies the post
+func wordpressPermalink(path string, query url.Values) (string, bool) {
+	if path != "/" && path != "/index.php" {
+		return "", false
+	}
+	for _, param := range wordpressParams {
+		if len(query.Get(param)) > 0 {
+			return param, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,77 @@
+package canonical
+
+import "testing"
+
+func TestCanonicalize(t *testing.T) {
+	c := New("ref")
+	tests := []struct {
+		rawURL        string
+		primaryDomain string
+		want          string
+		wantErr       bool
+	}{
+		{"HTTP://WWW.SVT.se:80/Nyheter/a/?utm_source=fb&b=2&a=1#top", "", "http://www.svt.se/Nyheter/a?a=1&b=2", false},
+		{"https://svt.se:443", "", "https://svt.se/", false},
+		{"https://svt.se:8443/a", "", "https://svt.se:8443/a", false},
+		{"https://svt.se/a?fbclid=x&ref=y&id=3", "", "https://svt.se/a?id=3", false},
+		{"http://mosaiq.example.com/?p=64187&utm_medium=x&preview=1", "", "http://mosaiq.example.com/?p=64187", false},
+		{"http://mosaiq.example.com/?p=64187", "https://www.Skonahem.com/", "http://www.skonahem.com/?p=64187", false},
+		{"http://mosaiq.example.com/index.php?page_id=7", "skonahem.com", "http://skonahem.com/index.php?page_id=7", false},
+		{"/relative/path", "", "", true},
+		{"ftp://svt.se/a", "", "", true},
+	}
+	for _, tt := range tests {
+		got, err := c.Canonicalize(tt.rawURL, tt.primaryDomain)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("Canonicalize(%q, %q) error = %v, wantErr = %t", tt.rawURL, tt.primaryDomain, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Canonicalize(%q, %q) got = %q, want = %q", tt.rawURL, tt.primaryDomain, got, tt.want)
+		}
+	}
+}
+
+func TestKey(t *testing.T) {
+	c := New()
+	same := []string{
+		"http://www.svt.se/nyheter/a",
+		"https://svt.se/nyheter/a/",
+		"https://svt.se/nyheter/a?utm_campaign=x#comments",
+	}
+	want, err := c.Key(same[0], "")
+	if err != nil {
+		t.Fatalf("Key(%q) unexpected error = %v", same[0], err)
+	}
+	for _, rawURL := range same[1:] {
+		if got, err := c.Key(rawURL, ""); err != nil || got != want {
+			t.Errorf("Key(%q) got = %s (error %v), want = %s", rawURL, got, err, want)
+		}
+	}
+	if got, _ := c.Key("https://svt.se/nyheter/b", ""); got == want {
+		t.Errorf("Key of a different article got the same key %s", got)
+	}
+}
+
+func TestPrimaryDomain(t *testing.T) {
+	c := New()
+	c.LoadPrimaryDomains(map[string]string{
+		"www.mosaiq.example.com":  "https://www.skonahem.com/",
+		"elle.mosaiq.example.com": "elle.se",
+	})
+	tests := []struct {
+		sourceURL string
+		want      string
+	}{
+		{"http://mosaiq.example.com/?p=1", "www.skonahem.com"},
+		{"http://cdn.mosaiq.example.com/?p=1", "www.skonahem.com"},
+		{"http://elle.mosaiq.example.com/?p=1", "elle.se"},
+		{"http://example.com/?p=1", ""},
+		{"not a url", ""},
+	}
+	for _, tt := range tests {
+		if got := c.PrimaryDomain(tt.sourceURL); got != tt.want {
+			t.Errorf("PrimaryDomain(%q) got = %q, want = %q", tt.sourceURL, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+)
+
+// Exit codes returned by the commands
+const (
+	exitOK      = 0
+	exitFailure = 1
+	exitUsage   = 2
+)
+
+// command is a subcommand of the binary
+type command struct {
+	name    string
+	summary string
+	run     func(args []string) int
+}
+
+var commands = map[string]command{}
+
+func registerCommand(c command) {
+	commands[c.name] = c
+}
+
+// runCommand runs the subcommand named by the first argument.
+// It returns false when there is no such subcommand.
+func runCommand(args []string) (int, bool) {
+	if len(args) == 0 {
+		return exitUsage, false
+	}
+	c, ok := commands[args[0]]
+	if !ok {
+		return exitUsage, false
+	}
+	return c.run(args[1:]), true
+}
+
+func printCommands() {
+	fmt.Fprintf(os.Stderr, "Usage: %s <command> [flags]\n\nCommands:\n", os.Args[0])
+	var names []string
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "  %-12s %s\n", name, commands[name].summary)
+	}
+}
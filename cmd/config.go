@@ -3,14 +3,29 @@ package main
 import (
 	"fmt"
 	"io"
+	"net/http"
 	"os"
+	"strconv"
+	"time"
 
 	log "github.com/Sirupsen/logrus"
 	"github.com/spf13/viper"
+
+	"github.com/jedynykaban/testkeyholder/images"
+)
+
+const (
+	configFileName = "testkeyholder"
 )
 
 const (
-	serviceConfigSectionName = "app"
+	serviceConfigSectionName    = "app"
+	datesConfigSectionName      = "dates"
+	serverConfigSectionName     = "server"
+	urlsConfigSectionName       = "urls"
+	validationConfigSectionName = "validation"
+	pipelineConfigSectionName   = "pipeline"
+	imagesConfigSectionName     = "images"
 )
 
 const (
@@ -19,6 +34,41 @@ const (
 	logFormatEntry = "logformat"
 )
 
+const (
+	serverAddrEntry            = "addr"
+	serverReadTimeoutEntry     = "readtimeout"
+	serverWriteTimeoutEntry    = "writetimeout"
+	serverShutdownTimeoutEntry = "shutdowntimeout"
+)
+
+const (
+	dateLayoutsEntry = "layouts"
+	dateZonesEntry   = "zones"
+)
+
+const (
+	urlsTrackingParamsEntry = "trackingparams"
+	urlsPrimaryDomainsEntry = "primarydomains"
+)
+
+const (
+	validationProfilesEntry = "profiles"
+)
+
+const (
+	imagesUpgradeHTTPSEntry = "upgradehttps"
+	imagesHTTPSHostsEntry   = "httpshosts"
+	imagesProbeEntry        = "probe"
+	imagesTimeoutEntry      = "timeout"
+	imagesMinWidthEntry     = "minwidth"
+	imagesMinHeightEntry    = "minheight"
+)
+
+const (
+	pipelineStepsEntry      = "steps"
+	pipelinePublishersEntry = "publishers"
+)
+
 // ServiceConfig is a base config for the service.
 type ServiceConfig struct {
 	LogLevel  log.Level
@@ -38,26 +88,179 @@ func (sc *ServiceConfig) log() {
 	log.Infoln("Service log format:", sc.LogFormat)
 }
 
+// DatesConfig holds the date layouts and default zones per publisher.
+// Both are keyed by the publisher ID or sourceURL host i.e. svt.se
+type DatesConfig struct {
+	Layouts map[string][]string
+	Zones   map[string]string
+}
+
+func (dc *DatesConfig) log() {
+	for key, layouts := range dc.Layouts {
+		log.Infof("Date layouts for %s: %v", key, layouts)
+	}
+	for key, zone := range dc.Zones {
+		log.Infof("Date zone for %s: %s", key, zone)
+	}
+}
+
+// URLsConfig holds the settings of the source URL canonicalisation.
+type URLsConfig struct {
+	// TrackingParams are stripped on top of utm_* and canonical.DefaultTrackingParams
+	TrackingParams []string
+	// PrimaryDomains maps the host of the publisher's sourceURLs to the domain
+	// the mitems created inside mosaiq link to i.e. skonahem.com
+	PrimaryDomains map[string]string
+}
+
+func (uc *URLsConfig) log() {
+	log.Infoln("Extra tracking parameters:", uc.TrackingParams)
+	for host, domain := range uc.PrimaryDomains {
+		log.Infof("Primary domain for %s: %s", host, domain)
+	}
+}
+
+// ValidationConfig holds the settings of the mitem validation.
+type ValidationConfig struct {
+	// Profiles are YAML or JSON files with validation profiles, see model.ValidationProfile
+	Profiles []string
+}
+
+func (vc *ValidationConfig) log() {
+	log.Infoln("Validation profiles:", vc.Profiles)
+}
+
+// PipelineConfig turns the processing steps on or off, see services.Pipeline.SetEnabled
+type PipelineConfig struct {
+	// Steps maps the step name to its default state for all publishers
+	Steps map[string]bool
+	// Publishers maps the publisher ID to the steps turned on or off for that publisher only
+	Publishers map[string]map[string]bool
+}
+
+// Toggles returns the steps' states keyed by publisher ID, the empty key holds the defaults
+func (pc *PipelineConfig) Toggles() map[string]map[string]bool {
+	ret := map[string]map[string]bool{"": pc.Steps}
+	for publisherID, steps := range pc.Publishers {
+		ret[publisherID] = steps
+	}
+	return ret
+}
+
+func (pc *PipelineConfig) log() {
+	for name, enabled := range pc.Steps {
+		log.Infof("Processing step %s enabled: %t", name, enabled)
+	}
+	for publisherID, steps := range pc.Publishers {
+		for name, enabled := range steps {
+			log.Infof("Processing step %s enabled for %s: %t", name, publisherID, enabled)
+		}
+	}
+}
+
+// ImagesConfig holds the settings of the image normalisation, see images.Options
+type ImagesConfig struct {
+	UpgradeHTTPS bool
+	HTTPSHosts   []string
+	// Probe fetches the images missing the dimensions
+	Probe     bool
+	Timeout   time.Duration
+	MinWidth  int
+	MinHeight int
+}
+
+// Options makes the image normalisation options, the images are probed over HTTP when Probe is set
+func (ic *ImagesConfig) Options() images.Options {
+	opts := images.Options{
+		UpgradeHTTPS: ic.UpgradeHTTPS,
+		HTTPSHosts:   ic.HTTPSHosts,
+		Timeout:      ic.Timeout,
+		MinWidth:     ic.MinWidth,
+		MinHeight:    ic.MinHeight,
+	}
+	if ic.Probe {
+		opts.Fetcher = images.NewHTTPFetcher(&http.Client{Timeout: ic.Timeout})
+	}
+	return opts
+}
+
+func (ic *ImagesConfig) log() {
+	log.Infoln("Images upgraded to https:", ic.UpgradeHTTPS)
+	log.Infoln("Images https hosts:", ic.HTTPSHosts)
+	log.Infoln("Images probed:", ic.Probe)
+	log.Infoln("Images probe timeout:", ic.Timeout)
+	log.Infof("Images minimum size: %dx%d", ic.MinWidth, ic.MinHeight)
+}
+
+// ServerConfig holds the settings of the HTTP server.
+type ServerConfig struct {
+	Addr            string
+	ReadTimeout     time.Duration
+	WriteTimeout    time.Duration
+	ShutdownTimeout time.Duration
+}
+
+func (sc *ServerConfig) log() {
+	log.Infoln("Server address:", sc.Addr)
+	log.Infoln("Server read timeout:", sc.ReadTimeout)
+	log.Infoln("Server write timeout:", sc.WriteTimeout)
+	log.Infoln("Server shutdown timeout:", sc.ShutdownTimeout)
+}
+
 // Log logs the settings stored in config.
 func (c *Config) Log() {
 	c.Service.log()
+	c.Dates.log()
+	c.Server.log()
+	c.URLs.log()
+	c.Validation.log()
+	c.Pipeline.log()
+	c.Images.log()
 }
 
 // Config is a full config.
 type Config struct {
-	Service ServiceConfig
+	Service    ServiceConfig
+	Dates      DatesConfig
+	Server     ServerConfig
+	URLs       URLsConfig
+	Validation ValidationConfig
+	Pipeline   PipelineConfig
+	Images     ImagesConfig
 }
 
 const (
 	serviceLogLevelDefault  = "info"
-	serviceLogOutputDefault = "stdout"
+	serviceLogOutputDefault = "stderr"
 	serviceLogFormatDefault = "json"
 )
 
+const (
+	serverAddrDefault            = ":8080"
+	serverReadTimeoutDefault     = "30s"
+	serverWriteTimeoutDefault    = "30s"
+	serverShutdownTimeoutDefault = "10s"
+)
+
+var (
+	imagesUpgradeHTTPSDefault = images.DefaultOptions.UpgradeHTTPS
+	imagesTimeoutDefault      = images.DefaultOptions.Timeout.String()
+	imagesMinWidthDefault     = images.DefaultOptions.MinWidth
+	imagesMinHeightDefault    = images.DefaultOptions.MinHeight
+)
+
 func setDefaults() {
 	viper.SetDefault(fmt.Sprintf("%s.%s", serviceConfigSectionName, logLevelEntry), serviceLogLevelDefault)
 	viper.SetDefault(fmt.Sprintf("%s.%s", serviceConfigSectionName, logOutputEntry), serviceLogOutputDefault)
 	viper.SetDefault(fmt.Sprintf("%s.%s", serviceConfigSectionName, logFormatEntry), serviceLogFormatDefault)
+	viper.SetDefault(fmt.Sprintf("%s.%s", serverConfigSectionName, serverAddrEntry), serverAddrDefault)
+	viper.SetDefault(fmt.Sprintf("%s.%s", serverConfigSectionName, serverReadTimeoutEntry), serverReadTimeoutDefault)
+	viper.SetDefault(fmt.Sprintf("%s.%s", serverConfigSectionName, serverWriteTimeoutEntry), serverWriteTimeoutDefault)
+	viper.SetDefault(fmt.Sprintf("%s.%s", serverConfigSectionName, serverShutdownTimeoutEntry), serverShutdownTimeoutDefault)
+	viper.SetDefault(fmt.Sprintf("%s.%s", imagesConfigSectionName, imagesUpgradeHTTPSEntry), imagesUpgradeHTTPSDefault)
+	viper.SetDefault(fmt.Sprintf("%s.%s", imagesConfigSectionName, imagesTimeoutEntry), imagesTimeoutDefault)
+	viper.SetDefault(fmt.Sprintf("%s.%s", imagesConfigSectionName, imagesMinWidthEntry), imagesMinWidthDefault)
+	viper.SetDefault(fmt.Sprintf("%s.%s", imagesConfigSectionName, imagesMinHeightEntry), imagesMinHeightDefault)
 }
 
 func translateLogLevel(level string) log.Level {
@@ -69,11 +272,12 @@ func translateLogLevel(level string) log.Level {
 	return lvl
 }
 
+// translateLogOutput keeps stdout for the command output i.e. JSON reports, the logs go to stderr unless stdout is asked for
 func translateLogOutput(out string) io.Writer {
-	if out == "stderr" {
-		return os.Stderr
+	if out == "stdout" {
+		return os.Stdout
 	}
-	return os.Stdout
+	return os.Stderr
 }
 
 func buildConfig() Config {
@@ -85,12 +289,94 @@ func buildConfig() Config {
 			LogOutput: logOutput,
 			LogFormat: viper.GetString(fmt.Sprintf("%s.%s", serviceConfigSectionName, logFormatEntry)),
 		},
+		Dates: DatesConfig{
+			Layouts: viper.GetStringMapStringSlice(fmt.Sprintf("%s.%s", datesConfigSectionName, dateLayoutsEntry)),
+			Zones:   viper.GetStringMapString(fmt.Sprintf("%s.%s", datesConfigSectionName, dateZonesEntry)),
+		},
+		Server: ServerConfig{
+			Addr:            viper.GetString(fmt.Sprintf("%s.%s", serverConfigSectionName, serverAddrEntry)),
+			ReadTimeout:     viper.GetDuration(fmt.Sprintf("%s.%s", serverConfigSectionName, serverReadTimeoutEntry)),
+			WriteTimeout:    viper.GetDuration(fmt.Sprintf("%s.%s", serverConfigSectionName, serverWriteTimeoutEntry)),
+			ShutdownTimeout: viper.GetDuration(fmt.Sprintf("%s.%s", serverConfigSectionName, serverShutdownTimeoutEntry)),
+		},
+		URLs: URLsConfig{
+			TrackingParams: viper.GetStringSlice(fmt.Sprintf("%s.%s", urlsConfigSectionName, urlsTrackingParamsEntry)),
+			PrimaryDomains: viper.GetStringMapString(fmt.Sprintf("%s.%s", urlsConfigSectionName, urlsPrimaryDomainsEntry)),
+		},
+		Validation: ValidationConfig{
+			Profiles: viper.GetStringSlice(fmt.Sprintf("%s.%s", validationConfigSectionName, validationProfilesEntry)),
+		},
+		Pipeline: PipelineConfig{
+			Steps:      getStringMapBool(fmt.Sprintf("%s.%s", pipelineConfigSectionName, pipelineStepsEntry)),
+			Publishers: getPublisherToggles(fmt.Sprintf("%s.%s", pipelineConfigSectionName, pipelinePublishersEntry)),
+		},
+		Images: ImagesConfig{
+			UpgradeHTTPS: viper.GetBool(fmt.Sprintf("%s.%s", imagesConfigSectionName, imagesUpgradeHTTPSEntry)),
+			HTTPSHosts:   viper.GetStringSlice(fmt.Sprintf("%s.%s", imagesConfigSectionName, imagesHTTPSHostsEntry)),
+			Probe:        viper.GetBool(fmt.Sprintf("%s.%s", imagesConfigSectionName, imagesProbeEntry)),
+			Timeout:      viper.GetDuration(fmt.Sprintf("%s.%s", imagesConfigSectionName, imagesTimeoutEntry)),
+			MinWidth:     viper.GetInt(fmt.Sprintf("%s.%s", imagesConfigSectionName, imagesMinWidthEntry)),
+			MinHeight:    viper.GetInt(fmt.Sprintf("%s.%s", imagesConfigSectionName, imagesMinHeightEntry)),
+		},
+	}
+}
+
+// getStringMapBool reads the map of step name to its state, viper has no getter for it
+func getStringMapBool(key string) map[string]bool {
+	return toToggles(key, viper.GetStringMap(key))
+}
+
+// getPublisherToggles reads the map of publisher ID to the map of step name to its state.
+// The nested maps are read from the values, publisher IDs like svt.se cannot be used in viper keys.
+func getPublisherToggles(key string) map[string]map[string]bool {
+	ret := make(map[string]map[string]bool)
+	for publisherID, value := range viper.GetStringMap(key) {
+		steps, ok := value.(map[string]interface{})
+		if !ok {
+			log.Warnf("Invalid value of %s.%s, want the map of step name to true or false: %v", key, publisherID, value)
+			continue
+		}
+		ret[publisherID] = toToggles(fmt.Sprintf("%s.%s", key, publisherID), steps)
+	}
+	return ret
+}
+
+func toToggles(key string, values map[string]interface{}) map[string]bool {
+	ret := make(map[string]bool)
+	for name, value := range values {
+		enabled, err := toBool(value)
+		if err != nil {
+			log.Warnf("Invalid value of %s.%s, want true or false: %v", key, name, value)
+			continue
+		}
+		ret[name] = enabled
+	}
+	return ret
+}
+
+func toBool(value interface{}) (bool, error) {
+	switch v := value.(type) {
+	case bool:
+		return v, nil
+	case string:
+		return strconv.ParseBool(v)
+	}
+	return false, fmt.Errorf("Unsupported value %v", value)
+}
+
+// readConfigFile reads the optional config file from the working directory
+func readConfigFile() {
+	viper.SetConfigName(configFileName)
+	viper.AddConfigPath(".")
+	if err := viper.ReadInConfig(); err != nil {
+		log.Debugf("No config file loaded: %v", err)
 	}
 }
 
 func getConfig() Config {
 	// set defaults first
 	setDefaults()
+	readConfigFile()
 
 	config := buildConfig()
 	return config
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+func init() {
+	registerCommand(command{
+		name:    "convert",
+		summary: "convert mitems into the TheNewMitem structure",
+		run:     runConvert,
+	})
+}
+
+// runConvert prints the converted mitems as JSON, one document per input.
+// It exits with exitFailure when at least one mitem could not be converted.
+func runConvert(args []string) int {
+	fs := newFlagSet("convert", "[flags] [file...]")
+	kf := addKojoFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return exitUsage
+	}
+	inputs, err := readInputs(fs.Args())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitFailure
+	}
+
+	kojo, err := kf.kojo()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitFailure
+	}
+	ret := exitOK
+	for _, in := range inputs {
+		mitem, err := kojo.ConvertMitem(in.data)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", in.name, err)
+			ret = exitFailure
+			continue
+		}
+		if err := printJSON(mitem); err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", in.name, err)
+			ret = exitFailure
+		}
+	}
+	return ret
+}
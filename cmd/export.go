@@ -0,0 +1,77 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/jedynykaban/testkeyholder/model"
+	"github.com/jedynykaban/testkeyholder/render"
+)
+
+func init() {
+	registerCommand(command{
+		name:    "export",
+		summary: "export mitems to AMP, Apple News or Instant Articles",
+		run:     runExport,
+	})
+}
+
+// runExport converts the mitems and prints them in the format, one document per input.
+// With -check the format's constraints are reported instead.
+// It exits with exitFailure when at least one mitem could not be exported.
+func runExport(args []string) int {
+	fs := newFlagSet("export", "[flags] [file...]")
+	format := fs.String("format", render.FormatAMP, "export format: "+strings.Join(render.Formats(), ", "))
+	language := fs.String("language", render.DefaultLanguage, "language of the exported documents")
+	check := fs.Bool("check", false, "only report what does not meet the format's constraints")
+	kf := addKojoFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return exitOK
+		}
+		return exitUsage
+	}
+	exporter, err := render.NewExporter(*format, *language)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitUsage
+	}
+	inputs, err := readInputs(fs.Args())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitFailure
+	}
+
+	kojo, err := kf.kojo()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitFailure
+	}
+	ret := exitOK
+	for _, in := range inputs {
+		mitem, err := kojo.ConvertMitem(in.data)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", in.name, err)
+			ret = exitFailure
+			continue
+		}
+		if *check {
+			errs := exporter.Check(mitem)
+			for _, err := range errs {
+				e := model.AsValidationError(err)
+				fmt.Printf("%s: %s %s: %s\n", in.name, e.Severity, e.Path, e.Message)
+			}
+			if model.HasErrors(errs) {
+				ret = exitFailure
+			}
+			continue
+		}
+		if err := exporter.Export(os.Stdout, mitem); err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", in.name, err)
+			ret = exitFailure
+		}
+	}
+	return ret
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/jedynykaban/testkeyholder/services"
+)
+
+func init() {
+	registerCommand(command{
+		name:    "extract",
+		summary: "extract a field from mitems with the Kojo getters",
+		run:     runExtract,
+	})
+}
+
+// extractResult is the JSON output of the extract command
+type extractResult struct {
+	Name  string      `json:"name"`
+	Value interface{} `json:"value,omitempty"`
+	Error string      `json:"error,omitempty"`
+}
+
+// runExtract exits with exitFailure when the field could not be extracted from at least one mitem
+func runExtract(args []string) int {
+	fs := newFlagSet("extract", "-field name [flags] [file...]")
+	field := fs.String("field", "", "field to extract, one of: "+strings.Join(services.FieldNames(), ", "))
+	asJSON := fs.Bool("json", false, "print JSON instead of text")
+	kf := addKojoFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return exitUsage
+	}
+	if !isField(*field) {
+		fmt.Fprintf(os.Stderr, "Unknown field %q, want one of: %s\n", *field, strings.Join(services.FieldNames(), ", "))
+		return exitUsage
+	}
+	inputs, err := readInputs(fs.Args())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitFailure
+	}
+
+	kojo, err := kf.kojo()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitFailure
+	}
+	ret := exitOK
+	var results []extractResult
+	for _, in := range inputs {
+		res := extractResult{Name: in.name}
+		pm, err := kojo.Parse(in.data)
+		if err == nil {
+			res.Value, err = pm.Field(*field)
+		}
+		if err != nil {
+			res.Error = err.Error()
+			ret = exitFailure
+		}
+		results = append(results, res)
+	}
+
+	if *asJSON {
+		if err := printJSON(results); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return exitFailure
+		}
+		return ret
+	}
+	for _, res := range results {
+		if len(res.Error) > 0 {
+			fmt.Printf("%s: error: %s\n", res.Name, res.Error)
+		} else {
+			fmt.Printf("%s: %v\n", res.Name, res.Value)
+		}
+	}
+	return ret
+}
+
+func isField(name string) bool {
+	for _, f := range services.FieldNames() {
+		if f == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/jedynykaban/testkeyholder/model"
+	"github.com/jedynykaban/testkeyholder/services"
+)
+
+// input is a single mitem read from a file or stdin
+type input struct {
+	name string
+	data json.RawMessage
+}
+
+// readInputs reads mitems from the files, no files or - mean stdin
+func readInputs(files []string) ([]input, error) {
+	if len(files) == 0 {
+		files = []string{"-"}
+	}
+	var ret []input
+	for _, file := range files {
+		var data []byte
+		var err error
+		if file == "-" {
+			data, err = ioutil.ReadAll(os.Stdin)
+		} else {
+			data, err = ioutil.ReadFile(file)
+		}
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, input{name: file, data: data})
+	}
+	return ret, nil
+}
+
+// kojoFlags are the flags shared by the commands using Kojo
+type kojoFlags struct {
+	utc      *bool
+	profiles *string
+}
+
+func addKojoFlags(fs *flag.FlagSet) kojoFlags {
+	return kojoFlags{
+		utc:      fs.Bool("utc", false, "normalise dates to UTC and reject incomplete or implausible ones"),
+		profiles: fs.String("profiles", "", "YAML or JSON `file` with validation profiles applied on top of the configured ones"),
+	}
+}
+
+func (kf kojoFlags) kojo() (services.Kojo, error) {
+	var opts []services.KojoOption
+	if *kf.utc {
+		opts = append(opts, services.WithUTCDates())
+	}
+	if len(*kf.profiles) > 0 {
+		vp := model.NewValidationProfiles()
+		if err := vp.Add(model.DefaultValidationProfiles.Profiles()...); err != nil {
+			return nil, err
+		}
+		if err := vp.LoadFiles(*kf.profiles); err != nil {
+			return nil, err
+		}
+		opts = append(opts, services.WithValidationProfiles(vp))
+	}
+	opts = append(opts, services.WithPipeline(newPipeline()))
+	return services.NewKojo(opts...), nil
+}
+
+// newPipeline creates the default pipeline with the image options and the steps turned on or off as configured
+func newPipeline() *services.Pipeline {
+	imageOpts := config.Images.Options()
+	p := services.DefaultPipeline(services.PipelineOptions{Images: &imageOpts})
+	p.LoadToggles(config.Pipeline.Toggles())
+	return p
+}
+
+// printJSON prints the value as indented JSON to stdout
+func printJSON(v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(data))
+	return nil
+}
+
+// newFlagSet creates a flag set that reports errors instead of exiting
+func newFlagSet(name, usage string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s %s\n", name, usage)
+		fs.PrintDefaults()
+	}
+	return fs
+}
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"cloud.google.com/go/datastore"
+)
+
+func init() {
+	registerCommand(command{
+		name:    "keys",
+		summary: "decode and encode datastore keys",
+		run:     runKeys,
+	})
+}
+
+// keyElement is a single element of the key path
+type keyElement struct {
+	Kind string `json:"kind"`
+	ID   int64  `json:"id,omitempty"`
+	Name string `json:"name,omitempty"`
+}
+
+// keyInfo describes a datastore key
+type keyInfo struct {
+	Encoded string `json:"encoded"`
+	keyElement
+	Namespace string `json:"namespace,omitempty"`
+	// Parents holds the parent chain, starting from the root
+	Parents []keyElement `json:"parents,omitempty"`
+	Error   string       `json:"error,omitempty"`
+}
+
+func describeKey(encoded string) keyInfo {
+	ret := keyInfo{Encoded: encoded}
+	key, err := datastore.DecodeKey(encoded)
+	if err != nil {
+		ret.Error = err.Error()
+		return ret
+	}
+	ret.keyElement = keyElement{Kind: key.Kind, ID: key.ID, Name: key.Name}
+	ret.Namespace = key.Namespace
+	for parent := key.Parent; parent != nil; parent = parent.Parent {
+		ret.Parents = append([]keyElement{{Kind: parent.Kind, ID: parent.ID, Name: parent.Name}}, ret.Parents...)
+	}
+	return ret
+}
+
+func (e keyElement) String() string {
+	if len(e.Name) > 0 {
+		return fmt.Sprintf("%s(%q)", e.Kind, e.Name)
+	}
+	return fmt.Sprintf("%s(%d)", e.Kind, e.ID)
+}
+
+func (ki keyInfo) String() string {
+	if len(ki.Error) > 0 {
+		return fmt.Sprintf("%s\terror: %s", ki.Encoded, ki.Error)
+	}
+	path := make([]string, 0, len(ki.Parents)+1)
+	for _, p := range ki.Parents {
+		path = append(path, p.String())
+	}
+	path = append(path, ki.keyElement.String())
+	ret := fmt.Sprintf("%s\t%s", ki.Encoded, strings.Join(path, "/"))
+	if len(ki.Namespace) > 0 {
+		ret += "\tnamespace=" + ki.Namespace
+	}
+	return ret
+}
+
+// parseKeyElement parses Kind:id or Kind:name
+func parseKeyElement(s string) (keyElement, error) {
+	parts := strings.SplitN(s, ":", 2)
+	if len(parts) != 2 || len(parts[0]) == 0 || len(parts[1]) == 0 {
+		return keyElement{}, fmt.Errorf("Invalid key element %q, want = Kind:id or Kind:name", s)
+	}
+	if id, err := strconv.ParseInt(parts[1], 10, 64); err == nil {
+		return keyElement{Kind: parts[0], ID: id}, nil
+	}
+	return keyElement{Kind: parts[0], Name: parts[1]}, nil
+}
+
+func makeKey(e keyElement, parent *datastore.Key, namespace string) *datastore.Key {
+	var key *datastore.Key
+	if len(e.Name) > 0 {
+		key = datastore.NameKey(e.Kind, e.Name, parent)
+	} else {
+		key = datastore.IDKey(e.Kind, e.ID, parent)
+	}
+	key.Namespace = namespace
+	return key
+}
+
+func runKeys(args []string) int {
+	if len(args) == 0 {
+		keysUsage()
+		return exitUsage
+	}
+	switch args[0] {
+	case "decode":
+		return runKeysDecode(args[1:])
+	case "encode":
+		return runKeysEncode(args[1:])
+	default:
+		keysUsage()
+		return exitUsage
+	}
+}
+
+func keysUsage() {
+	fmt.Fprintln(os.Stderr, "Usage: keys decode [-json] [-f file] [key...]")
+	fmt.Fprintln(os.Stderr, "       keys encode -kind Kind (-id N | -name S) [-namespace NS] [-parent Kind:id/Kind:name]")
+}
+
+func runKeysDecode(args []string) int {
+	fs := newFlagSet("keys decode", "[-json] [-f file] [key...]")
+	asJSON := fs.Bool("json", false, "print JSON lines instead of text")
+	file := fs.String("f", "", "read keys from the file, one per line (- for stdin)")
+	if err := fs.Parse(args); err != nil {
+		return exitUsage
+	}
+
+	encoded := fs.Args()
+	if len(*file) > 0 || len(encoded) == 0 {
+		var in io.Reader = os.Stdin
+		if len(*file) > 0 && *file != "-" {
+			f, err := os.Open(*file)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				return exitFailure
+			}
+			defer f.Close()
+			in = f
+		}
+		scanner := bufio.NewScanner(in)
+		for scanner.Scan() {
+			if line := strings.TrimSpace(scanner.Text()); len(line) > 0 {
+				encoded = append(encoded, line)
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return exitFailure
+		}
+	}
+
+	ret := exitOK
+	enc := json.NewEncoder(os.Stdout)
+	for _, e := range encoded {
+		info := describeKey(e)
+		if len(info.Error) > 0 {
+			ret = exitFailure
+		}
+		if *asJSON {
+			enc.Encode(info)
+		} else {
+			fmt.Println(info)
+		}
+	}
+	return ret
+}
+
+func runKeysEncode(args []string) int {
+	fs := newFlagSet("keys encode", "-kind Kind (-id N | -name S) [-namespace NS] [-parent Kind:id/Kind:name] [-json]")
+	kind := fs.String("kind", "", "kind of the key")
+	id := fs.Int64("id", 0, "numeric ID of the key")
+	name := fs.String("name", "", "name of the key")
+	namespace := fs.String("namespace", "", "namespace of the key")
+	parent := fs.String("parent", "", "parent chain from the root i.e. Publisher:123/Playlist:news")
+	asJSON := fs.Bool("json", false, "print JSON instead of text")
+	if err := fs.Parse(args); err != nil {
+		return exitUsage
+	}
+	if len(*kind) == 0 || (*id == 0) == (len(*name) == 0) {
+		fmt.Fprintln(os.Stderr, "Exactly one of -id and -name has to be set along with -kind")
+		return exitUsage
+	}
+
+	var parentKey *datastore.Key
+	if len(*parent) > 0 {
+		for _, p := range strings.Split(*parent, "/") {
+			e, err := parseKeyElement(p)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				return exitUsage
+			}
+			parentKey = makeKey(e, parentKey, *namespace)
+		}
+	}
+	key := makeKey(keyElement{Kind: *kind, ID: *id, Name: *name}, parentKey, *namespace)
+	info := describeKey(key.Encode())
+	if *asJSON {
+		json.NewEncoder(os.Stdout).Encode(info)
+	} else {
+		fmt.Println(info)
+	}
+	return exitOK
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/jedynykaban/testkeyholder/model"
+)
+
+func init() {
+	registerCommand(command{
+		name:    "parse-date",
+		summary: "show which date layout matches and what the date parses to",
+		run:     runParseDate,
+	})
+}
+
+// dateResult is the JSON output of the parse-date command
+type dateResult struct {
+	Input       string                   `json:"input"`
+	Time        string                   `json:"time,omitempty"`
+	Layout      string                   `json:"layout,omitempty"`
+	Key         string                   `json:"key,omitempty"`
+	Relaxed     bool                     `json:"relaxed,omitempty"`
+	ZoneGuessed bool                     `json:"zoneGuessed,omitempty"`
+	Errors      []*model.ValidationError `json:"errors,omitempty"`
+}
+
+// runParseDate exits with exitFailure when at least one date could not be parsed
+func runParseDate(args []string) int {
+	fs := newFlagSet("parse-date", "[flags] date...")
+	publisher := fs.String("publisher", "", "publisher key i.e. svt.se")
+	sourceURL := fs.String("sourceurl", "", "mitem sourceURL the publisher key is taken from")
+	utc := fs.Bool("utc", false, "normalise the date to UTC and reject incomplete or implausible ones")
+	asJSON := fs.Bool("json", false, "print JSON instead of text")
+	if err := fs.Parse(args); err != nil {
+		return exitUsage
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return exitUsage
+	}
+	keys := []string{*publisher}
+	if len(*sourceURL) > 0 {
+		keys = append(keys, model.PublisherKey(*sourceURL))
+	}
+
+	ret := exitOK
+	var results []dateResult
+	for _, value := range fs.Args() {
+		var match model.DateMatch
+		var errs []error
+		if *utc {
+			match, errs = model.DefaultDateLayouts.Normalize(value, keys...)
+		} else {
+			var err error
+			match, err = model.DefaultDateLayouts.Parse(value, keys...)
+			if err != nil {
+				errs = append(errs, err)
+			}
+		}
+		res := dateResult{Input: value}
+		for _, err := range errs {
+			res.Errors = append(res.Errors, model.AsValidationError(err))
+		}
+		if model.HasErrors(errs) {
+			ret = exitFailure
+		} else {
+			res.Time = match.Time.Format(time.RFC3339Nano)
+			res.Layout = match.Layout
+			res.Key = match.Key
+			res.Relaxed = match.Relaxed
+			res.ZoneGuessed = match.ZoneGuessed
+		}
+		results = append(results, res)
+	}
+
+	if *asJSON {
+		if err := printJSON(results); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return exitFailure
+		}
+		return ret
+	}
+	for _, res := range results {
+		if len(res.Time) > 0 {
+			fmt.Printf("%s: %s (layout %q", res.Input, res.Time, res.Layout)
+			if len(res.Key) > 0 {
+				fmt.Printf(", publisher %s", res.Key)
+			}
+			if res.Relaxed {
+				fmt.Print(", relaxed")
+			}
+			if res.ZoneGuessed {
+				fmt.Print(", zone guessed")
+			}
+			fmt.Println(")")
+		}
+		for _, e := range res.Errors {
+			fmt.Printf("%s: %s: %s\n", res.Input, e.Severity, e.Message)
+		}
+	}
+	return ret
+}
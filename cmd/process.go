@@ -0,0 +1,70 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/jedynykaban/testkeyholder/services"
+)
+
+func init() {
+	registerCommand(command{
+		name:    "process",
+		summary: "run mitems through the processing pipeline and print the processed ones",
+		run:     runProcess,
+	})
+}
+
+// processResult is the JSON output of the process command run with -report
+type processResult struct {
+	Name  string                `json:"name"`
+	Data  json.RawMessage       `json:"data,omitempty"`
+	Steps []services.StepResult `json:"steps,omitempty"`
+	Error string                `json:"error,omitempty"`
+}
+
+// runProcess exits with exitFailure when at least one mitem could not be processed
+func runProcess(args []string) int {
+	fs := newFlagSet("process", "[flags] [file...]")
+	publisher := fs.String("publisher", "", "publisher `ID` the steps are turned on or off for")
+	report := fs.Bool("report", false, "print what every step did along with the processed mitem")
+	kf := addKojoFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return exitUsage
+	}
+	inputs, err := readInputs(fs.Args())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitFailure
+	}
+
+	kojo, err := kf.kojo()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitFailure
+	}
+	ret := exitOK
+	for _, in := range inputs {
+		res := processResult{Name: in.name}
+		processed, err := kojo.ProcessFor(*publisher, in.data)
+		if err != nil {
+			res.Error = err.Error()
+			ret = exitFailure
+		} else {
+			res.Data, res.Steps = processed.Data, processed.Steps
+		}
+		if *report {
+			err = printJSON(res)
+		} else if len(res.Error) > 0 {
+			fmt.Fprintf(os.Stderr, "%s: error: %s\n", res.Name, res.Error)
+		} else {
+			err = printJSON(res.Data)
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return exitFailure
+		}
+	}
+	return ret
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/jedynykaban/testkeyholder/schema"
+)
+
+func init() {
+	registerCommand(command{
+		name:    "schema",
+		summary: "print the JSON Schema of the mitem formats",
+		run:     runSchema,
+	})
+}
+
+// runSchema prints the schema documents, the mitem we accept when none is named
+func runSchema(args []string) int {
+	fs := newFlagSet("schema", "[flags] ["+strings.Join(schema.Names(), "|")+"]")
+	output := fs.String("o", "", "write the schema to the file instead of stdout")
+	if err := fs.Parse(args); err != nil {
+		return exitUsage
+	}
+	if fs.NArg() > 1 {
+		fs.Usage()
+		return exitUsage
+	}
+	name := schema.NameMitemTiniest
+	if fs.NArg() == 1 {
+		name = fs.Arg(0)
+	}
+	doc, err := schema.Document(name)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitUsage
+	}
+	if len(*output) == 0 {
+		if err := printJSON(doc); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return exitFailure
+		}
+		return exitOK
+	}
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitFailure
+	}
+	if err := ioutil.WriteFile(*output, append(data, '\n'), 0644); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitFailure
+	}
+	return exitOK
+}
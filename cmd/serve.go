@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+
+	log "github.com/Sirupsen/logrus"
+
+	"github.com/jedynykaban/testkeyholder/server"
+)
+
+func init() {
+	registerCommand(command{
+		name:    "serve",
+		summary: "serve the validation and extraction HTTP API",
+		run:     runServe,
+	})
+}
+
+// runServe serves until SIGINT or SIGTERM, then it shuts the server down gracefully
+func runServe(args []string) int {
+	fs := newFlagSet("serve", "[flags]")
+	addr := fs.String("addr", config.Server.Addr, "address to listen on")
+	kf := addKojoFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return exitOK
+		}
+		return exitUsage
+	}
+
+	kojo, err := kf.kojo()
+	if err != nil {
+		log.WithFields(log.Fields{"error": err}).Error("Unable to set up kojo")
+		return exitFailure
+	}
+	srv, err := server.New(kojo)
+	if err != nil {
+		log.WithFields(log.Fields{"error": err}).Error("Unable to set up the server")
+		return exitFailure
+	}
+	httpServer := &http.Server{
+		Addr:         *addr,
+		Handler:      srv,
+		ReadTimeout:  config.Server.ReadTimeout,
+		WriteTimeout: config.Server.WriteTimeout,
+	}
+
+	// the server is ready once the port is bound, the connections are queued until Serve accepts them
+	ln, err := net.Listen("tcp", *addr)
+	if err != nil {
+		log.WithFields(log.Fields{"error": err}).Error("Unable to listen")
+		return exitFailure
+	}
+	errs := make(chan error, 1)
+	go func() {
+		log.Infof("Serving on %s", ln.Addr())
+		errs <- httpServer.Serve(ln)
+	}()
+	srv.SetReady(true)
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
+	select {
+	case err := <-errs:
+		log.WithFields(log.Fields{"error": err}).Error("Server failed")
+		return exitFailure
+	case sig := <-stop:
+		log.Infof("Received %v, shutting down", sig)
+	}
+
+	srv.SetReady(false)
+	ctx, cancel := context.WithTimeout(context.Background(), config.Server.ShutdownTimeout)
+	defer cancel()
+	if err := httpServer.Shutdown(ctx); err != nil {
+		log.WithFields(log.Fields{"error": err}).Error("Server shutdown failed")
+		return exitFailure
+	}
+	return exitOK
+}
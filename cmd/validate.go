@@ -0,0 +1,67 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/jedynykaban/testkeyholder/batch"
+)
+
+func init() {
+	registerCommand(command{
+		name:    "validate",
+		summary: "validate mitems read from files, JSONL files, directories or stdin",
+		run:     runValidate,
+	})
+}
+
+// runValidate exits with exitFailure when at least one mitem is invalid
+func runValidate(args []string) int {
+	fs := newFlagSet("validate", "[flags] [file|dir|-...]")
+	format := fs.String("format", batch.FormatText, "report format: text, json or junit")
+	output := fs.String("o", "", "write the report to the file instead of stdout")
+	workers := fs.Int("workers", 0, "number of concurrent workers, 0 means one per CPU")
+	againstSchema := fs.Bool("schema", false, "check the mitems against the JSON Schema instead of the validation rules")
+	kf := addKojoFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return exitUsage
+	}
+	if *format != batch.FormatText && *format != batch.FormatJSON && *format != batch.FormatJUnit {
+		fmt.Fprintf(os.Stderr, "Unsupported report format %s\n", *format)
+		return exitUsage
+	}
+	paths := fs.Args()
+	if len(paths) == 0 {
+		paths = []string{"-"}
+	}
+
+	kojo, err := kf.kojo()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitFailure
+	}
+	validator := batch.NewValidator(kojo, *workers)
+	if *againstSchema {
+		validator = batch.NewSchemaValidator(kojo, *workers)
+	}
+	report := validator.Run(batch.Read(paths))
+
+	out := os.Stdout
+	if len(*output) > 0 {
+		f, err := os.Create(*output)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return exitFailure
+		}
+		defer f.Close()
+		out = f
+	}
+	if err := report.Write(out, *format); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitFailure
+	}
+	if !report.Passed() {
+		return exitFailure
+	}
+	return exitOK
+}
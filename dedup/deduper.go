@@ -0,0 +1,247 @@
+// Package dedup detects incoming mitems we already have, either under the same
+// source URL or as near-duplicates i.e. the same syndicated article published by several sources.
+package dedup
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+
+	log "github.com/Sirupsen/logrus"
+
+	"github.com/jedynykaban/testkeyholder/model"
+	"github.com/jedynykaban/testkeyholder/repository"
+	"github.com/jedynykaban/testkeyholder/services"
+	"github.com/jedynykaban/testkeyholder/slug"
+)
+
+// Status tells how the incoming mitem relates to the known ones
+type Status string
+
+// Statuses of the dedup results
+const (
+	// StatusNew means none of the known mitems matched
+	StatusNew Status = "new"
+	// StatusUpdate means a known mitem has the same canonical source URL
+	StatusUpdate Status = "update"
+	// StatusNearDuplicate means a known mitem has very similar content or the same headline
+	StatusNearDuplicate Status = "near-duplicate"
+)
+
+// Default thresholds used by the dedupers created by New
+const (
+	DefaultMaxDistance         = 6
+	DefaultMaxHeadlineDistance = 12
+)
+
+// Fingerprint is what the deduper knows about a mitem
+type Fingerprint struct {
+	// MitemID is the DatabaseMitem.ID, empty for the incoming mitems
+	MitemID      string `json:"mitemID,omitempty"`
+	CanonicalURL string `json:"canonicalURL,omitempty"`
+	// URLKey is the dedup key of the canonical URL, see canonical.Hash
+	URLKey string `json:"urlKey,omitempty"`
+	// Headline is lowercased and transliterated, punctuation is dropped
+	Headline string `json:"headline,omitempty"`
+	// SimHash is the fingerprint of the paragraphs text
+	SimHash uint64 `json:"simHash"`
+	// HasContent is false for the mitems without any paragraph text
+	HasContent bool `json:"hasContent"`
+}
+
+// Result of checking the incoming mitem
+type Result struct {
+	Status Status `json:"status"`
+	// Match is the known mitem that matched, nil for StatusNew
+	Match *Fingerprint `json:"match,omitempty"`
+	// Similarity of the contents ranges from 0 to 1,
+	// it is 1 when the contents cannot be compared and the headlines are the same
+	Similarity  float64     `json:"similarity"`
+	Fingerprint Fingerprint `json:"fingerprint"`
+}
+
+// Deduper tells whether the incoming mitems are new
+type Deduper struct {
+	kojo  services.Kojo
+	store Store
+	// MaxDistance is the number of bits the content fingerprints can differ in for near-duplicates.
+	// Stores guarantee candidates differing in up to 7 bits only.
+	MaxDistance int
+	// MaxHeadlineDistance is used instead of MaxDistance when the headlines are the same
+	MaxHeadlineDistance int
+}
+
+// ErrNoMitemID is returned when a mitem without ID is added
+var ErrNoMitemID = errors.New("Unable to index mitem without ID")
+
+// New - ctor like function - creates a deduper, the in-memory store is used when store is nil
+func New(kojo services.Kojo, store Store) *Deduper {
+	if store == nil {
+		store = NewMemoryStore()
+	}
+	return &Deduper{
+		kojo:                kojo,
+		store:               store,
+		MaxDistance:         DefaultMaxDistance,
+		MaxHeadlineDistance: DefaultMaxHeadlineDistance,
+	}
+}
+
+// Fingerprint makes the fingerprint of the mitem.
+// Mitems without a valid sourceURL get fingerprint without URLKey.
+func (d *Deduper) Fingerprint(data json.RawMessage) (Fingerprint, error) {
+	pm, err := d.kojo.Parse(data)
+	if err != nil {
+		return Fingerprint{}, err
+	}
+	var ret Fingerprint
+	if canonicalURL, err := pm.CanonicalURL(); err == nil {
+		ret.CanonicalURL = canonicalURL
+		ret.URLKey, _ = pm.DedupKey()
+	} else {
+		log.WithFields(log.Fields{"error": err}).Debug("Mitem fingerprinted without source URL")
+	}
+	ret.Headline = NormaliseHeadline(pm.Tiniest().Headline)
+	body, err := model.DecodeBody(pm.Body())
+	if err != nil {
+		return Fingerprint{}, err
+	}
+	ws := words(paragraphsText(body))
+	ret.SimHash = SimHash(ws)
+	ret.HasContent = len(ws) > 0
+	return ret, nil
+}
+
+// Check tells whether the mitem is new, an update of a known mitem or a near-duplicate of one.
+// The mitem is not added to the store, see Add.
+func (d *Deduper) Check(ctx context.Context, data json.RawMessage) (*Result, error) {
+	fp, err := d.Fingerprint(data)
+	if err != nil {
+		return nil, err
+	}
+	ret := &Result{Status: StatusNew, Fingerprint: fp}
+	if len(fp.URLKey) > 0 {
+		known, err := d.store.ByURLKey(ctx, fp.URLKey)
+		if err != nil {
+			return nil, err
+		}
+		if match, similarity, ok := d.best(fp, known, true); ok {
+			ret.Status, ret.Match, ret.Similarity = StatusUpdate, match, similarity
+			return ret, nil
+		}
+	}
+
+	var candidates []Fingerprint
+	if fp.HasContent {
+		if candidates, err = d.store.Candidates(ctx, fp.SimHash); err != nil {
+			return nil, err
+		}
+	}
+	if len(fp.Headline) > 0 {
+		sameHeadline, err := d.store.ByHeadline(ctx, fp.Headline)
+		if err != nil {
+			return nil, err
+		}
+		candidates = append(candidates, sameHeadline...)
+	}
+	if match, similarity, ok := d.best(fp, candidates, false); ok {
+		ret.Status, ret.Match, ret.Similarity = StatusNearDuplicate, match, similarity
+	}
+	return ret, nil
+}
+
+// Add indexes the mitem under the given ID, the ID usually is the DatabaseMitem.ID
+func (d *Deduper) Add(ctx context.Context, mitemID string, data json.RawMessage) error {
+	if len(mitemID) == 0 {
+		return ErrNoMitemID
+	}
+	fp, err := d.Fingerprint(data)
+	if err != nil {
+		return err
+	}
+	fp.MitemID = mitemID
+	return d.store.Put(ctx, fp)
+}
+
+// AddMitem indexes the stored mitem
+func (d *Deduper) AddMitem(ctx context.Context, mitem *model.DatabaseMitem) error {
+	return d.Add(ctx, mitem.ID, mitem.Data)
+}
+
+// Remove drops the mitem from the index
+func (d *Deduper) Remove(ctx context.Context, mitemID string) error {
+	return d.store.Remove(ctx, mitemID)
+}
+
+// IndexRepository indexes all the mitems matching the query.
+// Mitems that cannot be fingerprinted are skipped and logged, the number of indexed mitems is returned.
+func (d *Deduper) IndexRepository(ctx context.Context, repo repository.MitemRepository, q repository.MitemQuery) (int, error) {
+	mitems, err := repo.Query(ctx, q)
+	if err != nil {
+		return 0, err
+	}
+	var ret int
+	for _, mitem := range mitems {
+		if err := d.AddMitem(ctx, mitem); err != nil {
+			log.WithFields(log.Fields{"error": err, "id": mitem.ID}).Warn("Unable to index the mitem")
+			continue
+		}
+		ret++
+	}
+	return ret, nil
+}
+
+// best picks the most similar of the known mitems. With sameURL set every mitem
+// matches, otherwise only the ones within the distance thresholds do.
+func (d *Deduper) best(fp Fingerprint, known []Fingerprint, sameURL bool) (*Fingerprint, float64, bool) {
+	var match *Fingerprint
+	var best float64
+	for idx := range known {
+		k := known[idx]
+		if len(fp.MitemID) > 0 && k.MitemID == fp.MitemID {
+			continue
+		}
+		similarity, ok := d.similarity(fp, k)
+		if !ok && !sameURL {
+			continue
+		}
+		if match == nil || similarity > best || (similarity == best && k.MitemID < match.MitemID) {
+			match, best = &k, similarity
+		}
+	}
+	return match, best, match != nil
+}
+
+// similarity compares the contents, or the headlines when either mitem has no content,
+// and tells whether the mitems are near-duplicates
+func (d *Deduper) similarity(a, b Fingerprint) (float64, bool) {
+	sameHeadline := len(a.Headline) > 0 && a.Headline == b.Headline
+	if !a.HasContent || !b.HasContent {
+		if sameHeadline {
+			return 1, true
+		}
+		return 0, false
+	}
+	distance := Distance(a.SimHash, b.SimHash)
+	if sameHeadline {
+		return Similarity(a.SimHash, b.SimHash), distance <= d.MaxHeadlineDistance
+	}
+	return Similarity(a.SimHash, b.SimHash), distance <= d.MaxDistance
+}
+
+// NormaliseHeadline lowercases and transliterates the headline dropping the punctuation
+func NormaliseHeadline(headline string) string {
+	return strings.Replace(slug.Make(headline), "-", " ", -1)
+}
+
+// paragraphsText joins the text of all the paragraphs, gallery ones included
+func paragraphsText(body []model.BodyElement) string {
+	var texts []string
+	model.WalkBody(body, func(path string, element model.BodyElement) {
+		if paragraph, ok := element.(*model.ParagraphElement); ok {
+			texts = append(texts, paragraph.Text())
+		}
+	})
+	return strings.Join(texts, " ")
+}
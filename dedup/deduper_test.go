@@ -0,0 +1,101 @@
+package dedup
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/jedynykaban/testkeyholder/services"
+)
+
+// article makes the text of n words, the same seed gives the same text
+func article(seed string, n int) string {
+	var ws []string
+	for i := 0; i < n; i++ {
+		ws = append(ws, fmt.Sprintf("%s%d", seed, i*7%13+i))
+	}
+	return strings.Join(ws, " ")
+}
+
+func mitem(sourceURL, headline, text string) json.RawMessage {
+	return json.RawMessage(fmt.Sprintf(`{
+		"sourceURL": %q,
+		"date": "2020-01-02T10:00:00Z",
+		"type": "article",
+		"headline": %q,
+		"body": [{"type": "paragraph", "content": %q}]
+	}`, sourceURL, headline, text))
+}
+
+func TestSimHash(t *testing.T) {
+	text := words(article("ord", 200))
+	edited := append([]string{}, text...)
+	edited[100] = "changed"
+
+	if SimHash(nil) != 0 {
+		t.Errorf("SimHash of no words got = %d, want = 0", SimHash(nil))
+	}
+	if got := Distance(SimHash(text), SimHash(text)); got != 0 {
+		t.Errorf("Distance of the same text got = %d, want = 0", got)
+	}
+	if got := Distance(SimHash(text), SimHash(edited)); got > DefaultMaxDistance {
+		t.Errorf("Distance of text with one word changed got = %d, want <= %d", got, DefaultMaxDistance)
+	}
+	if got := Distance(SimHash(text), SimHash(words(article("other", 200)))); got <= DefaultMaxDistance {
+		t.Errorf("Distance of unrelated texts got = %d, want > %d", got, DefaultMaxDistance)
+	}
+	if got := words("<p>Hello, <b>World</b>!</p> 2020"); strings.Join(got, " ") != "hello world 2020" {
+		t.Errorf("words got = %q", got)
+	}
+}
+
+func TestDeduper(t *testing.T) {
+	ctx := context.Background()
+	d := New(services.NewKojo(), nil)
+	text := article("ord", 200)
+	if err := d.Add(ctx, "1", mitem("https://www.svt.se/nyheter/a", "Storm i Norrland", text)); err != nil {
+		t.Fatalf("Add unexpected error = %v", err)
+	}
+	if err := d.Add(ctx, "", mitem("https://www.svt.se/nyheter/b", "b", text)); err != ErrNoMitemID {
+		t.Errorf("Add without ID error = %v, want = %v", err, ErrNoMitemID)
+	}
+
+	edited := strings.Replace(text, "ord100", "changed", 1)
+	tests := []struct {
+		name  string
+		data  json.RawMessage
+		want  Status
+		match string
+	}{
+		{"same url", mitem("https://svt.se/nyheter/a?utm_source=fb", "Storm i Norrland", "Completely rewritten text"), StatusUpdate, "1"},
+		{"syndicated copy", mitem("https://www.aftonbladet.se/x", "Other headline", edited), StatusNearDuplicate, "1"},
+		{"same headline without content", mitem("https://www.expressen.se/y", "Storm i Norrland!", ""), StatusNearDuplicate, "1"},
+		{"unrelated", mitem("https://www.dn.se/z", "Sol i Skåne", article("other", 200)), StatusNew, ""},
+	}
+	for _, tt := range tests {
+		got, err := d.Check(ctx, tt.data)
+		if err != nil {
+			t.Errorf("%s: Check unexpected error = %v", tt.name, err)
+			continue
+		}
+		if got.Status != tt.want {
+			t.Errorf("%s: Check status got = %s, want = %s", tt.name, got.Status, tt.want)
+		}
+		var match string
+		if got.Match != nil {
+			match = got.Match.MitemID
+		}
+		if match != tt.match {
+			t.Errorf("%s: Check match got = %q, want = %q", tt.name, match, tt.match)
+		}
+	}
+
+	if err := d.Remove(ctx, "1"); err != nil {
+		t.Fatalf("Remove unexpected error = %v", err)
+	}
+	if got, err := d.Check(ctx, mitem("https://www.svt.se/nyheter/a", "Storm i Norrland", text)); err != nil || got.Status != StatusNew {
+		t.Errorf("Check of removed mitem got = %+v, error = %v", got, err)
+	}
+}
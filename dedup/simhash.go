@@ -0,0 +1,67 @@
+package dedup
+
+import (
+	"hash/fnv"
+	"math/bits"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// shingleSize is the number of words hashed together
+const shingleSize = 3
+
+var markup = regexp.MustCompile(`<[^>]*>`)
+
+// words splits the text into lowercased words, markup is dropped
+func words(text string) []string {
+	text = markup.ReplaceAllString(text, " ")
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// SimHash makes the 64 bit fingerprint of the words.
+// Similar texts have fingerprints differing in few bits only, see Distance.
+// The words are hashed as overlapping shingles of three, a text without words has fingerprint 0.
+func SimHash(ws []string) uint64 {
+	if len(ws) == 0 {
+		return 0
+	}
+	var weights [64]int
+	add := func(token string) {
+		h := fnv.New64a()
+		h.Write([]byte(token))
+		sum := h.Sum64()
+		for bit := uint(0); bit < 64; bit++ {
+			if sum&(1<<bit) != 0 {
+				weights[bit]++
+			} else {
+				weights[bit]--
+			}
+		}
+	}
+	if len(ws) < shingleSize {
+		add(strings.Join(ws, " "))
+	}
+	for idx := 0; idx+shingleSize <= len(ws); idx++ {
+		add(strings.Join(ws[idx:idx+shingleSize], " "))
+	}
+	var ret uint64
+	for bit := uint(0); bit < 64; bit++ {
+		if weights[bit] > 0 {
+			ret |= 1 << bit
+		}
+	}
+	return ret
+}
+
+// Distance is the number of bits the fingerprints differ in
+func Distance(a, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
+
+// Similarity turns the distance of the fingerprints into a score from 0 to 1
+func Similarity(a, b uint64) float64 {
+	return 1 - float64(Distance(a, b))/64
+}
@@ -0,0 +1,152 @@
+package dedup
+
+import (
+	"context"
+	"sync"
+)
+
+// bands the fingerprints are split into for the near-duplicate lookups.
+// Fingerprints differing in fewer bits than there are bands share at least one band.
+const bands = 8
+
+// Store keeps fingerprints of the known mitems
+type Store interface {
+	// Put stores the fingerprint replacing the one of the same MitemID
+	Put(ctx context.Context, fp Fingerprint) error
+	// Remove drops the fingerprint of the mitem, removing unknown mitem is not an error
+	Remove(ctx context.Context, mitemID string) error
+	// ByURLKey returns the fingerprints of the mitems with the given dedup key
+	ByURLKey(ctx context.Context, key string) ([]Fingerprint, error)
+	// ByHeadline returns the fingerprints of the mitems with the given normalised headline
+	ByHeadline(ctx context.Context, headline string) ([]Fingerprint, error)
+	// Candidates returns the fingerprints possibly close to the content fingerprint,
+	// it has to return at least all the ones differing in fewer than 8 bits
+	Candidates(ctx context.Context, simHash uint64) ([]Fingerprint, error)
+}
+
+// memoryStore is an in-memory Store indexing fingerprints by the dedup key,
+// the headline and the bands of the content fingerprint
+type memoryStore struct {
+	mu        sync.RWMutex
+	mitems    map[string]Fingerprint
+	urls      map[string]map[string]bool
+	headlines map[string]map[string]bool
+	bands     [bands]map[uint8]map[string]bool
+}
+
+var _ Store = &memoryStore{}
+
+// NewMemoryStore - ctor like function - creates an empty in-memory store
+func NewMemoryStore() Store {
+	ms := &memoryStore{
+		mitems:    make(map[string]Fingerprint),
+		urls:      make(map[string]map[string]bool),
+		headlines: make(map[string]map[string]bool),
+	}
+	for idx := range ms.bands {
+		ms.bands[idx] = make(map[uint8]map[string]bool)
+	}
+	return ms
+}
+
+// Put implements Store
+func (ms *memoryStore) Put(ctx context.Context, fp Fingerprint) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	ms.remove(fp.MitemID)
+	ms.mitems[fp.MitemID] = fp
+	index(ms.urls, fp.URLKey, fp.MitemID)
+	index(ms.headlines, fp.Headline, fp.MitemID)
+	if fp.HasContent {
+		for idx := range ms.bands {
+			band := band(fp.SimHash, idx)
+			if ms.bands[idx][band] == nil {
+				ms.bands[idx][band] = make(map[string]bool)
+			}
+			ms.bands[idx][band][fp.MitemID] = true
+		}
+	}
+	return nil
+}
+
+// Remove implements Store
+func (ms *memoryStore) Remove(ctx context.Context, mitemID string) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	ms.remove(mitemID)
+	return nil
+}
+
+// ByURLKey implements Store
+func (ms *memoryStore) ByURLKey(ctx context.Context, key string) ([]Fingerprint, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+	return ms.lookup(ms.urls[key]), nil
+}
+
+// ByHeadline implements Store
+func (ms *memoryStore) ByHeadline(ctx context.Context, headline string) ([]Fingerprint, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+	return ms.lookup(ms.headlines[headline]), nil
+}
+
+// Candidates implements Store
+func (ms *memoryStore) Candidates(ctx context.Context, simHash uint64) ([]Fingerprint, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+	ids := make(map[string]bool)
+	for idx := range ms.bands {
+		for id := range ms.bands[idx][band(simHash, idx)] {
+			ids[id] = true
+		}
+	}
+	return ms.lookup(ids), nil
+}
+
+func (ms *memoryStore) remove(mitemID string) {
+	fp, ok := ms.mitems[mitemID]
+	if !ok {
+		return
+	}
+	delete(ms.mitems, mitemID)
+	unindex(ms.urls, fp.URLKey, mitemID)
+	unindex(ms.headlines, fp.Headline, mitemID)
+	for idx := range ms.bands {
+		band := band(fp.SimHash, idx)
+		delete(ms.bands[idx][band], mitemID)
+		if len(ms.bands[idx][band]) == 0 {
+			delete(ms.bands[idx], band)
+		}
+	}
+}
+
+func (ms *memoryStore) lookup(ids map[string]bool) []Fingerprint {
+	var ret []Fingerprint
+	for id := range ids {
+		ret = append(ret, ms.mitems[id])
+	}
+	return ret
+}
+
+func index(idx map[string]map[string]bool, key, mitemID string) {
+	if len(key) == 0 {
+		return
+	}
+	if idx[key] == nil {
+		idx[key] = make(map[string]bool)
+	}
+	idx[key][mitemID] = true
+}
+
+func unindex(idx map[string]map[string]bool, key, mitemID string) {
+	delete(idx[key], mitemID)
+	if len(idx[key]) == 0 {
+		delete(idx, key)
+	}
+}
+
+// band returns the idx-th byte of the fingerprint
+func band(simHash uint64, idx int) uint8 {
+	return uint8(simHash >> (uint(idx) * 8))
+}
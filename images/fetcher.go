@@ -0,0 +1,59 @@
+// Package images normalises the images of the mitems: it resolves their sources,
+// upgrades them to https and probes their dimensions.
+package images
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+)
+
+// Fetcher reads the first bytes of the image, that is enough to tell its dimensions
+type Fetcher interface {
+	// FetchHeader returns at most n first bytes of the resource
+	FetchHeader(ctx context.Context, url string, n int) ([]byte, error)
+}
+
+// FetcherFunc is an adapter allowing one to use a function as Fetcher
+type FetcherFunc func(ctx context.Context, url string, n int) ([]byte, error)
+
+// FetchHeader implements Fetcher
+func (f FetcherFunc) FetchHeader(ctx context.Context, url string, n int) ([]byte, error) {
+	return f(ctx, url, n)
+}
+
+// httpFetcher fetches the header bytes with an HTTP range request.
+// Servers ignoring the Range header are read up to n bytes only.
+type httpFetcher struct {
+	client *http.Client
+}
+
+// NewHTTPFetcher - ctor like function - creates Fetcher using the client, http.DefaultClient when nil
+func NewHTTPFetcher(client *http.Client) Fetcher {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &httpFetcher{client: client}
+}
+
+// FetchHeader implements Fetcher
+func (hf *httpFetcher) FetchHeader(ctx context.Context, url string, n int) ([]byte, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Range", "bytes=0-"+strconv.Itoa(n-1))
+	res, err := hf.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK && res.StatusCode != http.StatusPartialContent {
+		return nil, fmt.Errorf("Unable to fetch image %s, status = %s", url, res.Status)
+	}
+	return ioutil.ReadAll(io.LimitReader(res.Body, int64(n)))
+}
@@ -0,0 +1,78 @@
+package images
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Options of the image normalisation
+type Options struct {
+	// UpgradeHTTPS rewrites http sources to https
+	UpgradeHTTPS bool
+	// HTTPSHosts limits the upgrade to the hosts (and their subdomains) known to serve https,
+	// all the hosts are upgraded when empty
+	HTTPSHosts []string
+	// Fetcher probes the images missing the dimensions, they are not probed when nil
+	Fetcher Fetcher
+	// Timeout of a single probe
+	Timeout time.Duration
+	// MinWidth and MinHeight flag the smaller images, 0 turns the check off
+	MinWidth  int
+	MinHeight int
+}
+
+// DefaultOptions upgrade all the hosts and do not probe the images
+var DefaultOptions = Options{
+	UpgradeHTTPS: true,
+	Timeout:      5 * time.Second,
+	MinWidth:     100,
+	MinHeight:    100,
+}
+
+// ResolveSource makes the image source absolute resolving it against the mitem's sourceURL
+// and upgrades it to https when allowed by the options
+func (o Options) ResolveSource(source, sourceURL string) (string, error) {
+	source = strings.TrimSpace(source)
+	src, err := url.Parse(source)
+	if err != nil {
+		return "", fmt.Errorf("Unable to parse image source %s, error = %s", source, err.Error())
+	}
+	if !src.IsAbs() || len(src.Host) == 0 {
+		base, err := url.Parse(strings.TrimSpace(sourceURL))
+		if err != nil || !base.IsAbs() || len(base.Host) == 0 {
+			return "", fmt.Errorf("Unable to resolve relative image source %s without valid sourceURL", source)
+		}
+		src = base.ResolveReference(src)
+	}
+	if src.Scheme != "http" && src.Scheme != "https" {
+		return "", fmt.Errorf("Image source %s is not http(s) URL", source)
+	}
+	if src.Scheme == "http" && o.UpgradeHTTPS && o.httpsAllowed(src.Hostname()) {
+		src.Scheme = "https"
+	}
+	return src.String(), nil
+}
+
+// TooSmall tells whether the image is below the minimum size, images of unknown size are not
+func (o Options) TooSmall(width, height int) bool {
+	if width <= 0 || height <= 0 {
+		return false
+	}
+	return width < o.MinWidth || height < o.MinHeight
+}
+
+func (o Options) httpsAllowed(host string) bool {
+	if len(o.HTTPSHosts) == 0 {
+		return true
+	}
+	host = strings.ToLower(host)
+	for _, allowed := range o.HTTPSHosts {
+		allowed = strings.ToLower(allowed)
+		if host == allowed || strings.HasSuffix(host, "."+allowed) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,49 @@
+package images
+
+import "testing"
+
+func TestResolveSource(t *testing.T) {
+	opts := DefaultOptions
+	opts.HTTPSHosts = []string{"svt.se"}
+	tests := []struct {
+		source    string
+		sourceURL string
+		want      string
+		wantErr   bool
+	}{
+		{"http://www.svt.se/a.jpg", "", "https://www.svt.se/a.jpg", false},
+		{"http://img.example.com/a.jpg", "", "http://img.example.com/a.jpg", false},
+		{"/images/a.jpg", "http://svt.se/nyheter/x", "https://svt.se/images/a.jpg", false},
+		{"a.jpg", "https://example.com/news/x", "https://example.com/news/a.jpg", false},
+		{"//cdn.svt.se/a.jpg", "http://svt.se/x", "https://cdn.svt.se/a.jpg", false},
+		{"a.jpg", "", "", true},
+		{"data:image/png;base64,AAAA", "https://svt.se/x", "", true},
+	}
+	for _, tt := range tests {
+		got, err := opts.ResolveSource(tt.source, tt.sourceURL)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ResolveSource(%q, %q) error = %v, wantErr = %t", tt.source, tt.sourceURL, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ResolveSource(%q, %q) got = %q, want = %q", tt.source, tt.sourceURL, got, tt.want)
+		}
+	}
+}
+
+func TestTooSmall(t *testing.T) {
+	tests := []struct {
+		width, height int
+		want          bool
+	}{
+		{640, 360, false},
+		{99, 360, true},
+		{640, 50, true},
+		{0, 0, false},
+	}
+	for _, tt := range tests {
+		if got := DefaultOptions.TooSmall(tt.width, tt.height); got != tt.want {
+			t.Errorf("TooSmall(%d, %d) got = %t, want = %t", tt.width, tt.height, got, tt.want)
+		}
+	}
+}
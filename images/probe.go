@@ -0,0 +1,76 @@
+package images
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	// decoders of the formats image.DecodeConfig recognises
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+)
+
+// HeaderSize is the number of bytes fetched to probe the image.
+// JPEG files may have large metadata before the dimensions, thus it is rather generous.
+const HeaderSize = 64 << 10
+
+// ErrUnknownFormat is returned when the image is neither JPEG, PNG, GIF nor WebP
+var ErrUnknownFormat = errors.New("Unknown image format")
+
+// Dimensions of the probed image
+type Dimensions struct {
+	Width  int
+	Height int
+	// Format i.e. jpeg, png, gif, webp
+	Format string
+}
+
+// Probe fetches the header of the image and reads its dimensions
+func Probe(ctx context.Context, f Fetcher, url string) (Dimensions, error) {
+	header, err := f.FetchHeader(ctx, url, HeaderSize)
+	if err != nil {
+		return Dimensions{}, err
+	}
+	return Decode(header)
+}
+
+// Decode reads the dimensions out of the image's header
+func Decode(header []byte) (Dimensions, error) {
+	if d, ok := decodeWebP(header); ok {
+		return d, nil
+	}
+	config, format, err := image.DecodeConfig(bytes.NewReader(header))
+	if err == image.ErrFormat {
+		return Dimensions{}, ErrUnknownFormat
+	}
+	if err != nil {
+		return Dimensions{}, fmt.Errorf("Unable to read image dimensions, error = %s", err.Error())
+	}
+	return Dimensions{Width: config.Width, Height: config.Height, Format: format}, nil
+}
+
+// decodeWebP reads the dimensions of lossy (VP8), lossless (VP8L) and extended (VP8X) WebP images
+func decodeWebP(b []byte) (Dimensions, bool) {
+	if len(b) < 30 || string(b[0:4]) != "RIFF" || string(b[8:12]) != "WEBP" {
+		return Dimensions{}, false
+	}
+	ret := Dimensions{Format: "webp"}
+	switch string(b[12:16]) {
+	case "VP8 ":
+		ret.Width = int(binary.LittleEndian.Uint16(b[26:28]) & 0x3fff)
+		ret.Height = int(binary.LittleEndian.Uint16(b[28:30]) & 0x3fff)
+	case "VP8L":
+		bits := binary.LittleEndian.Uint32(b[21:25])
+		ret.Width = int(bits&0x3fff) + 1
+		ret.Height = int((bits>>14)&0x3fff) + 1
+	case "VP8X":
+		ret.Width = int(uint32(b[24])|uint32(b[25])<<8|uint32(b[26])<<16) + 1
+		ret.Height = int(uint32(b[27])|uint32(b[28])<<8|uint32(b[29])<<16) + 1
+	default:
+		return Dimensions{}, false
+	}
+	return ret, true
+}
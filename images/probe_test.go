@@ -0,0 +1,84 @@
+package images
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+func encoded(t *testing.T, format string, width, height int) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	var buf bytes.Buffer
+	var err error
+	switch format {
+	case "png":
+		err = png.Encode(&buf, img)
+	case "jpeg":
+		err = jpeg.Encode(&buf, img, nil)
+	case "gif":
+		err = gif.Encode(&buf, img, nil)
+	}
+	if err != nil {
+		t.Fatalf("Unable to encode %s, error = %v", format, err)
+	}
+	return buf.Bytes()
+}
+
+// webpVP8X makes the header of the extended WebP file, the canvas size is stored minus one on 24 bits
+func webpVP8X(width, height int) []byte {
+	b := make([]byte, 30)
+	copy(b[0:], "RIFF")
+	copy(b[8:], "WEBPVP8X")
+	w, h := width-1, height-1
+	b[24], b[25], b[26] = byte(w), byte(w>>8), byte(w>>16)
+	b[27], b[28], b[29] = byte(h), byte(h>>8), byte(h>>16)
+	return b
+}
+
+func TestDecode(t *testing.T) {
+	tests := []struct {
+		name   string
+		header []byte
+		want   Dimensions
+	}{
+		{"png", encoded(t, "png", 640, 360), Dimensions{640, 360, "png"}},
+		{"jpeg", encoded(t, "jpeg", 300, 200), Dimensions{300, 200, "jpeg"}},
+		{"gif", encoded(t, "gif", 1, 1), Dimensions{1, 1, "gif"}},
+		{"webp", webpVP8X(1920, 1080), Dimensions{1920, 1080, "webp"}},
+	}
+	for _, tt := range tests {
+		got, err := Decode(tt.header)
+		if err != nil {
+			t.Errorf("%s: Decode unexpected error = %v", tt.name, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s: Decode got = %+v, want = %+v", tt.name, got, tt.want)
+		}
+	}
+
+	if _, err := Decode([]byte("<html>not an image</html>")); err != ErrUnknownFormat {
+		t.Errorf("Decode of HTML got error = %v, want = %v", err, ErrUnknownFormat)
+	}
+}
+
+func TestProbe(t *testing.T) {
+	header := encoded(t, "png", 800, 450)
+	f := FetcherFunc(func(ctx context.Context, url string, n int) ([]byte, error) {
+		if n != HeaderSize {
+			t.Errorf("FetchHeader asked for %d bytes, want = %d", n, HeaderSize)
+		}
+		return header, nil
+	})
+	got, err := Probe(context.Background(), f, "https://img.example.com/a.png")
+	if err != nil {
+		t.Fatalf("Probe unexpected error = %v", err)
+	}
+	if want := (Dimensions{800, 450, "png"}); got != want {
+		t.Errorf("Probe got = %+v, want = %+v", got, want)
+	}
+}
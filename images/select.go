@@ -0,0 +1,97 @@
+package images
+
+import (
+	"net/url"
+	"path"
+	"strings"
+)
+
+// Candidate is an image considered for the main image
+type Candidate struct {
+	Source  string
+	Caption string
+	Width   int
+	Height  int
+}
+
+// Aspect ratios (width / height) of the images fitting the main image slot best,
+// the score of the ones outside the range drops along with the distance to it
+const (
+	MinPreferredRatio = 1.2
+	MaxPreferredRatio = 2.0
+)
+
+// trackingPixelSize is the largest side of the image considered a tracking pixel
+const trackingPixelSize = 2
+
+// trackingPatterns are the fragments of the tracking pixels' and logos' URLs
+var trackingPatterns = []string{"pixel", "tracking", "tracker", "beacon", "spacer", "blank.gif", "logo"}
+
+// IsDecorative tells whether the image is a tracking pixel, a spacer or a logo
+func IsDecorative(c Candidate) bool {
+	if (c.Width > 0 && c.Width <= trackingPixelSize) || (c.Height > 0 && c.Height <= trackingPixelSize) {
+		return true
+	}
+	u, err := url.Parse(strings.ToLower(c.Source))
+	if err != nil {
+		return true
+	}
+	name := path.Base(u.Path)
+	for _, pattern := range trackingPatterns {
+		if strings.Contains(name, pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+// Score ranks the candidate by its area weighted by the aspect ratio, images of unknown size score 1
+func Score(c Candidate) float64 {
+	if c.Width <= 0 || c.Height <= 0 {
+		return 1
+	}
+	ratio := float64(c.Width) / float64(c.Height)
+	weight := 1.0
+	switch {
+	case ratio < MinPreferredRatio:
+		weight = ratio / MinPreferredRatio
+	case ratio > MaxPreferredRatio:
+		weight = MaxPreferredRatio / ratio
+	}
+	return float64(c.Width*c.Height) * weight * weight
+}
+
+// Best picks the highest scoring candidate skipping tracking pixels and the images matching one of the logos.
+// The earlier candidate wins a tie, false is returned when there is nothing to pick.
+func Best(candidates []Candidate, logos ...string) (Candidate, bool) {
+	var best Candidate
+	var bestScore float64
+	for _, c := range candidates {
+		if len(strings.TrimSpace(c.Source)) == 0 || IsDecorative(c) || matchesAny(c.Source, logos) {
+			continue
+		}
+		if score := Score(c); score > bestScore {
+			best, bestScore = c, score
+		}
+	}
+	return best, bestScore > 0
+}
+
+// matchesAny compares the URLs ignoring the scheme, the query and the case
+func matchesAny(source string, urls []string) bool {
+	key := urlKey(source)
+	for _, u := range urls {
+		if len(strings.TrimSpace(u)) > 0 && urlKey(u) == key {
+			return true
+		}
+	}
+	return false
+}
+
+func urlKey(rawURL string) string {
+	u, err := url.Parse(strings.ToLower(strings.TrimSpace(rawURL)))
+	if err != nil {
+		return rawURL
+	}
+	return strings.TrimPrefix(u.Host, "www.") + strings.TrimSuffix(u.Path, "/")
+}
@@ -0,0 +1,68 @@
+package images
+
+import "testing"
+
+func TestIsDecorative(t *testing.T) {
+	tests := []struct {
+		candidate Candidate
+		want      bool
+	}{
+		{Candidate{Source: "https://svt.se/photo.jpg", Width: 1280, Height: 720}, false},
+		{Candidate{Source: "https://svt.se/photo.jpg"}, false},
+		{Candidate{Source: "https://svt.se/photo.jpg", Width: 1, Height: 1}, true},
+		{Candidate{Source: "https://stats.example.com/pixel.gif?id=1"}, true},
+		{Candidate{Source: "https://svt.se/img/spacer.png", Width: 300, Height: 200}, true},
+		{Candidate{Source: "https://svt.se/SVT-Logo.png", Width: 300, Height: 200}, true},
+	}
+	for _, tt := range tests {
+		if got := IsDecorative(tt.candidate); got != tt.want {
+			t.Errorf("IsDecorative(%+v) got = %t, want = %t", tt.candidate, got, tt.want)
+		}
+	}
+}
+
+func TestBest(t *testing.T) {
+	tests := []struct {
+		name       string
+		candidates []Candidate
+		logos      []string
+		want       string
+		wantOK     bool
+	}{
+		{
+			name:       "largest landscape image wins",
+			candidates: []Candidate{{Source: "https://a/1.jpg", Width: 640, Height: 360}, {Source: "https://a/2.jpg", Width: 1280, Height: 720}},
+			want:       "https://a/2.jpg",
+			wantOK:     true,
+		},
+		{
+			name:       "tall image loses to a smaller landscape one",
+			candidates: []Candidate{{Source: "https://a/tall.jpg", Width: 600, Height: 2400}, {Source: "https://a/wide.jpg", Width: 800, Height: 450}},
+			want:       "https://a/wide.jpg",
+			wantOK:     true,
+		},
+		{
+			name:       "the earlier image of unknown size wins a tie",
+			candidates: []Candidate{{Source: "https://a/1.jpg"}, {Source: "https://a/2.jpg"}},
+			want:       "https://a/1.jpg",
+			wantOK:     true,
+		},
+		{
+			name:       "the publisher's logo is skipped",
+			candidates: []Candidate{{Source: "http://www.svt.se/brand.png?v=2", Width: 1200, Height: 630}, {Source: "https://svt.se/photo.jpg", Width: 400, Height: 300}},
+			logos:      []string{"https://svt.se/brand.png"},
+			want:       "https://svt.se/photo.jpg",
+			wantOK:     true,
+		},
+		{
+			name:       "nothing but tracking pixels",
+			candidates: []Candidate{{Source: "https://a/pixel.gif"}, {Source: "https://a/x.gif", Width: 1, Height: 1}, {Source: " "}},
+		},
+	}
+	for _, tt := range tests {
+		got, ok := Best(tt.candidates, tt.logos...)
+		if ok != tt.wantOK || got.Source != tt.want {
+			t.Errorf("%s: Best got = %q (%t), want = %q (%t)", tt.name, got.Source, ok, tt.want, tt.wantOK)
+		}
+	}
+}
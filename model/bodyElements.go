@@ -0,0 +1,368 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// BodyElement is implemented by all the typed body elements
+type BodyElement interface {
+	// ElementType returns the value of the type field i.e. paragraph
+	ElementType() string
+}
+
+// TextElement is implemented by the body elements holding text content:
+// paragraphs, headings, info and subhead
+type TextElement interface {
+	BodyElement
+	Text() string
+	SetText(content string)
+}
+
+// Extra holds the fields of a body element its typed structure does not model i.e. image credit or element id.
+// They are kept as they were, thus decoding and encoding the element back loses nothing.
+type Extra map[string]json.RawMessage
+
+// ParagraphElement is a paragraph of text
+type ParagraphElement struct {
+	Content string
+	Extra   Extra
+}
+
+// HeadingElement is a heading, Level ranges from 1 (h1) to 6 (h6)
+type HeadingElement struct {
+	Level   int
+	Content string
+	Extra   Extra
+}
+
+// InfoElement is an info box
+type InfoElement struct {
+	Content string
+	Extra   Extra
+}
+
+// SubheadElement is a subhead
+type SubheadElement struct {
+	Content string
+	Extra   Extra
+}
+
+// ImageElement is an image embedded in the body
+type ImageElement struct {
+	Source  string
+	Caption string
+	Height  int
+	Width   int
+	Extra   Extra
+}
+
+// VideoElement is a video embedded in the body.
+// EmbedURL and ThumbnailURL are optional, they are filled by the video normalisation.
+type VideoElement struct {
+	Source       string
+	VideoType    string
+	EmbedURL     string
+	ThumbnailURL string
+	Extra        Extra
+}
+
+// GalleryElement is a collection of nested body elements
+type GalleryElement struct {
+	Body  []BodyElement
+	Extra Extra
+}
+
+// UnknownElement holds a body element of a type we don't model, or one whose fields
+// do not fit its typed structure i.e. an image with a non-integer width.
+// It is kept as is, thus encoding it back produces the very same JSON.
+type UnknownElement struct {
+	Type string
+	Raw  json.RawMessage
+}
+
+// ElementType implements BodyElement
+func (e ParagraphElement) ElementType() string { return ElementTypeParagraph }
+
+// ElementType implements BodyElement
+func (e HeadingElement) ElementType() string { return "h" + strconv.Itoa(e.Level) }
+
+// ElementType implements BodyElement
+func (e InfoElement) ElementType() string { return ElementTypeInfo }
+
+// ElementType implements BodyElement
+func (e SubheadElement) ElementType() string { return ElementTypeSubhead }
+
+// ElementType implements BodyElement
+func (e ImageElement) ElementType() string { return ElementTypeImage }
+
+// ElementType implements BodyElement
+func (e VideoElement) ElementType() string { return ElementTypeVideo }
+
+// ElementType implements BodyElement
+func (e GalleryElement) ElementType() string { return ElementTypeGallery }
+
+// ElementType implements BodyElement
+func (e UnknownElement) ElementType() string { return e.Type }
+
+// Text implements TextElement
+func (e *ParagraphElement) Text() string { return e.Content }
+
+// SetText implements TextElement
+func (e *ParagraphElement) SetText(content string) { e.Content = content }
+
+// Text implements TextElement
+func (e *HeadingElement) Text() string { return e.Content }
+
+// SetText implements TextElement
+func (e *HeadingElement) SetText(content string) { e.Content = content }
+
+// Text implements TextElement
+func (e *InfoElement) Text() string { return e.Content }
+
+// SetText implements TextElement
+func (e *InfoElement) SetText(content string) { e.Content = content }
+
+// Text implements TextElement
+func (e *SubheadElement) Text() string { return e.Content }
+
+// SetText implements TextElement
+func (e *SubheadElement) SetText(content string) { e.Content = content }
+
+// MarshalJSON implements json.Marshaler
+func (e ParagraphElement) MarshalJSON() ([]byte, error) {
+	return marshalElement(bodyCommonTiniest{bodyElement{e.ElementType()}, e.Content}, e.Extra)
+}
+
+// MarshalJSON implements json.Marshaler
+func (e HeadingElement) MarshalJSON() ([]byte, error) {
+	return marshalElement(bodyCommonTiniest{bodyElement{e.ElementType()}, e.Content}, e.Extra)
+}
+
+// MarshalJSON implements json.Marshaler
+func (e InfoElement) MarshalJSON() ([]byte, error) {
+	return marshalElement(bodyCommonTiniest{bodyElement{e.ElementType()}, e.Content}, e.Extra)
+}
+
+// MarshalJSON implements json.Marshaler
+func (e SubheadElement) MarshalJSON() ([]byte, error) {
+	return marshalElement(bodyCommonTiniest{bodyElement{e.ElementType()}, e.Content}, e.Extra)
+}
+
+// MarshalJSON implements json.Marshaler
+func (e ImageElement) MarshalJSON() ([]byte, error) {
+	return marshalElement(bodyImageTiniest{bodyElement{e.ElementType()}, e.Source, e.Caption, e.Height, e.Width}, e.Extra)
+}
+
+// MarshalJSON implements json.Marshaler
+func (e VideoElement) MarshalJSON() ([]byte, error) {
+	return marshalElement(bodyVideoTiniest{bodyElement{e.ElementType()}, e.Source, e.VideoType, e.EmbedURL, e.ThumbnailURL}, e.Extra)
+}
+
+// MarshalJSON implements json.Marshaler
+func (e GalleryElement) MarshalJSON() ([]byte, error) {
+	body, err := EncodeBody(e.Body)
+	if err != nil {
+		return nil, err
+	}
+	return marshalElement(bodyGalleryTiniest{bodyElement{e.ElementType()}, body}, e.Extra)
+}
+
+// MarshalJSON implements json.Marshaler
+func (e UnknownElement) MarshalJSON() ([]byte, error) {
+	return e.Raw, nil
+}
+
+// ElementWireTypes returns zero values of the structures the body elements are encoded with,
+// keyed by the element type. It allows one to describe the wire format i.e. with JSON Schema.
+func ElementWireTypes() map[string]interface{} {
+	return map[string]interface{}{
+		ElementTypeParagraph: bodyCommonTiniest{},
+		ElementTypeH1:        bodyCommonTiniest{},
+		ElementTypeH2:        bodyCommonTiniest{},
+		ElementTypeH3:        bodyCommonTiniest{},
+		ElementTypeH4:        bodyCommonTiniest{},
+		ElementTypeH5:        bodyCommonTiniest{},
+		ElementTypeH6:        bodyCommonTiniest{},
+		ElementTypeInfo:      bodyCommonTiniest{},
+		ElementTypeSubhead:   bodyCommonTiniest{},
+		ElementTypeImage:     bodyImageTiniest{},
+		ElementTypeVideo:     bodyVideoTiniest{},
+		ElementTypeGallery:   bodyGalleryTiniest{},
+	}
+}
+
+// DecodeBodyElement decodes a single body element dispatching on its type field.
+// Elements of unknown type and the elements whose fields cannot be read into their typed structure
+// are returned as *UnknownElement, thus a single odd element does not fail the whole body.
+// Only the elements that are not JSON objects are rejected.
+func DecodeBodyElement(data json.RawMessage) (BodyElement, error) {
+	var element bodyElement
+	if err := json.Unmarshal(data, &element); err != nil {
+		return nil, fmt.Errorf("Unable to unmarshal body element, error = %s", err.Error())
+	}
+	switch element.Type {
+	case ElementTypeParagraph, ElementTypeInfo, ElementTypeSubhead,
+		ElementTypeH1, ElementTypeH2, ElementTypeH3, ElementTypeH4, ElementTypeH5, ElementTypeH6:
+		var common bodyCommonTiniest
+		if err := json.Unmarshal(data, &common); err != nil {
+			return unknownElement(element.Type, data), nil
+		}
+		extra := extraFields(data, "type", "content")
+		switch element.Type {
+		case ElementTypeParagraph:
+			return &ParagraphElement{Content: common.Content, Extra: extra}, nil
+		case ElementTypeInfo:
+			return &InfoElement{Content: common.Content, Extra: extra}, nil
+		case ElementTypeSubhead:
+			return &SubheadElement{Content: common.Content, Extra: extra}, nil
+		default:
+			level, _ := strconv.Atoi(strings.TrimPrefix(element.Type, "h"))
+			return &HeadingElement{Level: level, Content: common.Content, Extra: extra}, nil
+		}
+
+	case ElementTypeImage:
+		var image bodyImageTiniest
+		if err := json.Unmarshal(data, &image); err != nil {
+			return unknownElement(element.Type, data), nil
+		}
+		extra := extraFields(data, "type", "source", "caption", "height", "width")
+		return &ImageElement{Source: image.Source, Caption: image.Caption, Height: image.Height, Width: image.Width, Extra: extra}, nil
+
+	case ElementTypeVideo:
+		var video bodyVideoTiniest
+		if err := json.Unmarshal(data, &video); err != nil {
+			return unknownElement(element.Type, data), nil
+		}
+		extra := extraFields(data, "type", "source", "videoType", "embedURL", "thumbnailURL")
+		return &VideoElement{Source: video.Source, VideoType: video.VideoType, EmbedURL: video.EmbedURL, ThumbnailURL: video.ThumbnailURL, Extra: extra}, nil
+
+	case ElementTypeGallery:
+		var gallery bodyGalleryTiniest
+		if err := json.Unmarshal(data, &gallery); err != nil {
+			return unknownElement(element.Type, data), nil
+		}
+		body, err := DecodeBody(gallery.Body)
+		if err != nil {
+			return unknownElement(element.Type, data), nil
+		}
+		return &GalleryElement{Body: body, Extra: extraFields(data, "type", "body")}, nil
+
+	default:
+		return unknownElement(element.Type, data), nil
+	}
+}
+
+// unknownElement keeps a copy of the element as is
+func unknownElement(elementType string, data json.RawMessage) *UnknownElement {
+	raw := make(json.RawMessage, len(data))
+	copy(raw, data)
+	return &UnknownElement{Type: elementType, Raw: raw}
+}
+
+// DecodeBody decodes all the body elements, it stops on the first element that is not a JSON object
+func DecodeBody(datas []json.RawMessage) ([]BodyElement, error) {
+	if datas == nil {
+		return nil, nil
+	}
+	ret := make([]BodyElement, 0, len(datas))
+	for idx, data := range datas {
+		element, err := DecodeBodyElement(data)
+		if err != nil {
+			return nil, fmt.Errorf("Body element %d: %s", idx, err.Error())
+		}
+		ret = append(ret, element)
+	}
+	return ret, nil
+}
+
+// extraFields returns the fields of the element other than the known ones, nil when there are none.
+// Known fields are matched case-insensitively, the same way encoding/json matches them.
+func extraFields(data json.RawMessage, known ...string) Extra {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil
+	}
+	var ret Extra
+	for name, value := range fields {
+		if containsFold(known, name) {
+			continue
+		}
+		if ret == nil {
+			ret = make(Extra)
+		}
+		ret[name] = value
+	}
+	return ret
+}
+
+// marshalElement encodes the wire structure of the element merging the extra fields back in,
+// the modelled fields win over the extra ones
+func marshalElement(wire interface{}, extra Extra) ([]byte, error) {
+	data, err := json.Marshal(wire)
+	if err != nil || len(extra) == 0 {
+		return data, err
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	for name, value := range extra {
+		if _, ok := fields[name]; !ok {
+			fields[name] = value
+		}
+	}
+	return json.Marshal(fields)
+}
+
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// EncodeBody encodes typed body elements back into raw body
+func EncodeBody(elements []BodyElement) (Body, error) {
+	if elements == nil {
+		return nil, nil
+	}
+	ret := make(Body, 0, len(elements))
+	for _, element := range elements {
+		data, err := json.Marshal(element)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, data)
+	}
+	return ret, nil
+}
+
+// Elements decodes the body into typed body elements
+func (b Body) Elements() ([]BodyElement, error) {
+	return DecodeBody(b)
+}
+
+// WalkBody calls fn for every element of the body, gallery children included.
+// Galleries are visited before their children. The path is the JSON pointer
+// of the element within the mitem i.e. /body/2/body/0.
+func WalkBody(elements []BodyElement, fn func(path string, element BodyElement)) {
+	walkBody(elements, "/body", fn)
+}
+
+func walkBody(elements []BodyElement, path string, fn func(path string, element BodyElement)) {
+	for idx, element := range elements {
+		elementPath := JSONPointer(path, idx)
+		fn(elementPath, element)
+		switch gallery := element.(type) {
+		case *GalleryElement:
+			walkBody(gallery.Body, JSONPointer(elementPath, "body"), fn)
+		case GalleryElement:
+			walkBody(gallery.Body, JSONPointer(elementPath, "body"), fn)
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package model
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestBodyRoundTrip(t *testing.T) {
+	body := Body{
+		json.RawMessage(`{"type":"paragraph","content":"Hej <b>du</b>","id":"p1"}`),
+		json.RawMessage(`{"type":"h2","content":"Rubrik"}`),
+		json.RawMessage(`{"type":"image","source":"https://a.se/i.jpg","caption":"c","height":10,"width":20,"credit":"Foto: TT"}`),
+		json.RawMessage(`{"type":"video","source":"abc","videoType":"youtube","autoplay":true}`),
+		json.RawMessage(`{"type":"gallery","body":[{"type":"image","source":"x","caption":"","height":0,"width":0,"credit":"TT"}],"layout":"grid"}`),
+		json.RawMessage(`{"type":"table","rows":[[1,2]]}`),
+	}
+	elements, err := DecodeBody(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	encoded, err := EncodeBody(elements)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(encoded) != len(body) {
+		t.Fatalf("got %d elements, want %d", len(encoded), len(body))
+	}
+	for idx := range body {
+		if !sameDocument(t, body[idx], encoded[idx]) {
+			t.Errorf("element %d: got %s, want %s", idx, encoded[idx], body[idx])
+		}
+	}
+}
+
+func TestDecodeBodyElementTypes(t *testing.T) {
+	tests := []struct {
+		data string
+		want string
+	}{
+		{`{"type":"paragraph","content":"x"}`, ElementTypeParagraph},
+		{`{"type":"h4","content":"x"}`, ElementTypeH4},
+		{`{"type":"info","content":"x"}`, ElementTypeInfo},
+		{`{"type":"subhead","content":"x"}`, ElementTypeSubhead},
+		{`{"type":"image","source":"x"}`, ElementTypeImage},
+		{`{"type":"video","source":"x","videoType":"mp4"}`, ElementTypeVideo},
+		{`{"type":"gallery","body":[]}`, ElementTypeGallery},
+		{`{"type":"quote","text":"x"}`, "quote"},
+	}
+	for _, test := range tests {
+		element, err := DecodeBodyElement(json.RawMessage(test.data))
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", test.data, err)
+			continue
+		}
+		if element.ElementType() != test.want {
+			t.Errorf("%s: got type %s, want %s", test.data, element.ElementType(), test.want)
+		}
+	}
+	if _, err := DecodeBodyElement(json.RawMessage(`"not an object"`)); err == nil {
+		t.Error("element that is not an object: expected an error")
+	}
+}
+
+func TestDecodeBodyKeepsOddElements(t *testing.T) {
+	body := Body{
+		json.RawMessage(`{"type":"paragraph","content":"x"}`),
+		json.RawMessage(`{"type":"image","source":"a.jpg","width":"1280","height":720}`),
+		json.RawMessage(`{"type":"gallery","body":[{"type":"image","source":1},{"type":"image","source":"b.jpg","caption":"","height":0,"width":0}]}`),
+	}
+	elements, err := DecodeBody(body)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if _, ok := elements[0].(*ParagraphElement); !ok {
+		t.Errorf("element 0: got %T, want *ParagraphElement", elements[0])
+	}
+	if e, ok := elements[1].(*UnknownElement); !ok || e.ElementType() != ElementTypeImage {
+		t.Errorf("element 1: got %#v, want *UnknownElement of type image", elements[1])
+	}
+	gallery, ok := elements[2].(*GalleryElement)
+	if !ok || len(gallery.Body) != 2 {
+		t.Fatalf("element 2: got %#v, want gallery of 2 elements", elements[2])
+	}
+	if _, ok := gallery.Body[0].(*UnknownElement); !ok {
+		t.Errorf("gallery element 0: got %T, want *UnknownElement", gallery.Body[0])
+	}
+	encoded, err := EncodeBody(elements)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for idx := range body {
+		if !sameDocument(t, body[idx], encoded[idx]) {
+			t.Errorf("element %d: got %s, want %s", idx, encoded[idx], body[idx])
+		}
+	}
+	if _, err := DecodeBody(Body{json.RawMessage(`{"type":"paragraph"}`), json.RawMessage(`42`)}); err == nil {
+		t.Error("body with element that is not an object: expected an error")
+	}
+}
+
+func TestWalkBody(t *testing.T) {
+	elements, err := DecodeBody(Body{
+		json.RawMessage(`{"type":"paragraph","content":"x"}`),
+		json.RawMessage(`{"type":"gallery","body":[{"type":"image","source":"a.jpg"},{"type":"video","source":"v"}]}`),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	WalkBody(elements, func(path string, element BodyElement) {
+		got = append(got, path+" "+element.ElementType())
+	})
+	want := "/body/0 paragraph,/body/1 gallery,/body/1/body/0 image,/body/1/body/1 video"
+	if strings.Join(got, ",") != want {
+		t.Errorf("got %s, want %s", strings.Join(got, ","), want)
+	}
+}
+
+func TestModelledFieldsWinOverExtra(t *testing.T) {
+	e := ImageElement{Source: "new", Extra: Extra{"source": json.RawMessage(`"old"`), "credit": json.RawMessage(`"TT"`)}}
+	data, err := json.Marshal(e)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"caption":"","credit":"TT","height":0,"source":"new","type":"image","width":0}`
+	if string(data) != want {
+		t.Errorf("got %s, want %s", data, want)
+	}
+}
+
+// sameDocument compares JSON documents ignoring the order of the fields
+func sameDocument(t *testing.T, a, b json.RawMessage) bool {
+	var va, vb interface{}
+	if err := json.Unmarshal(a, &va); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(b, &vb); err != nil {
+		t.Fatal(err)
+	}
+	ea, _ := json.Marshal(va)
+	eb, _ := json.Marshal(vb)
+	return string(ea) == string(eb)
+}
@@ -0,0 +1,124 @@
+package model
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/jedynykaban/testkeyholder/sanitize"
+)
+
+// DefaultWordsPerMinute is the reading speed used to estimate the reading time
+const DefaultWordsPerMinute = 200
+
+// DefaultExcerptLength is the maximum length of the excerpt in characters
+const DefaultExcerptLength = 200
+
+// TextOptions tells what the plain text of the mitem is made of
+type TextOptions struct {
+	// Headline puts the headline before the body text
+	Headline bool
+	// Captions includes the image captions, gallery ones included
+	Captions bool
+	// WordsPerMinute is the reading speed, DefaultWordsPerMinute is used when not set
+	WordsPerMinute int
+	// ExcerptLength limits the excerpt, DefaultExcerptLength is used when not set
+	ExcerptLength int
+}
+
+// DefaultTextOptions are used when converting mitems
+var DefaultTextOptions = TextOptions{Captions: true}
+
+// TextStats describes the text of the mitem
+type TextStats struct {
+	Text           string `json:"text"`
+	WordCount      int    `json:"wordCount"`
+	ReadingMinutes int    `json:"readingMinutes"`
+	Excerpt        string `json:"excerpt"`
+}
+
+// AnalyzeText makes the plain text of the mitem and derives the stats out of it
+func AnalyzeText(headline string, elements []BodyElement, opts TextOptions) TextStats {
+	text := PlainText(headline, elements, opts)
+	words := WordCount(text)
+	return TextStats{
+		Text:           text,
+		WordCount:      words,
+		ReadingMinutes: ReadingMinutes(words, opts.WordsPerMinute),
+		Excerpt:        Excerpt(elements, opts.ExcerptLength),
+	}
+}
+
+// PlainText renders the body as plain text, gallery children included.
+// Markup is stripped, blocks are separated with an empty line. Videos have no text.
+func PlainText(headline string, elements []BodyElement, opts TextOptions) string {
+	var blocks []string
+	add := func(text string) {
+		if text = sanitize.Text(text); len(text) > 0 {
+			blocks = append(blocks, text)
+		}
+	}
+	if opts.Headline {
+		add(headline)
+	}
+	WalkBody(elements, func(path string, element BodyElement) {
+		switch e := element.(type) {
+		case TextElement:
+			add(e.Text())
+		case *ImageElement:
+			if opts.Captions {
+				add(e.Caption)
+			}
+		}
+	})
+	return strings.Join(blocks, "\n\n")
+}
+
+// WordCount counts the words of the plain text, punctuation alone is not a word
+func WordCount(text string) int {
+	var ret int
+	for _, field := range strings.Fields(text) {
+		if strings.IndexFunc(field, isWordRune) >= 0 {
+			ret++
+		}
+	}
+	return ret
+}
+
+// ReadingMinutes estimates the reading time rounding it up to full minutes,
+// DefaultWordsPerMinute is used when wordsPerMinute is not positive
+func ReadingMinutes(words int, wordsPerMinute int) int {
+	if wordsPerMinute <= 0 {
+		wordsPerMinute = DefaultWordsPerMinute
+	}
+	return (words + wordsPerMinute - 1) / wordsPerMinute
+}
+
+// Excerpt returns the plain text of the first non-empty paragraph
+// cut at a word boundary to maxLength characters, DefaultExcerptLength is used when maxLength is not positive
+func Excerpt(elements []BodyElement, maxLength int) string {
+	if maxLength <= 0 {
+		maxLength = DefaultExcerptLength
+	}
+	var ret string
+	WalkBody(elements, func(path string, element BodyElement) {
+		if paragraph, ok := element.(*ParagraphElement); ok && len(ret) == 0 {
+			ret = sanitize.Text(paragraph.Content)
+		}
+	})
+	if utf8.RuneCountInString(ret) <= maxLength {
+		return ret
+	}
+	runes := []rune(ret)
+	cut := string(runes[:maxLength])
+	if !unicode.IsSpace(runes[maxLength]) {
+		if idx := strings.LastIndexFunc(cut, unicode.IsSpace); idx > 0 {
+			cut = cut[:idx]
+		}
+	}
+	return strings.TrimRightFunc(cut, func(r rune) bool { return unicode.IsSpace(r) || unicode.IsPunct(r) }) + "…"
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
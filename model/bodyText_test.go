@@ -0,0 +1,91 @@
+package model
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestAnalyzeText(t *testing.T) {
+	elements, err := DecodeBody(Body{
+		json.RawMessage(`{"type":"paragraph","content":""}`),
+		json.RawMessage(`{"type":"paragraph","content":"Hej <b>du</b>, hur m&aring;r du?"}`),
+		json.RawMessage(`{"type":"h2","content":"Rubrik"}`),
+		json.RawMessage(`{"type":"image","source":"a.jpg","caption":"Bildtext"}`),
+		json.RawMessage(`{"type":"video","source":"abc","videoType":"youtube"}`),
+		json.RawMessage(`{"type":"gallery","body":[{"type":"image","source":"b.jpg","caption":"Galleri – bild"}]}`),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name  string
+		opts  TextOptions
+		text  string
+		words int
+	}{
+		{"default", DefaultTextOptions, "Hej du, hur mår du?\n\nRubrik\n\nBildtext\n\nGalleri – bild", 9},
+		{"without captions", TextOptions{}, "Hej du, hur mår du?\n\nRubrik", 6},
+		{"with headline", TextOptions{Headline: true}, "Nyheter\n\nHej du, hur mår du?\n\nRubrik", 7},
+	}
+	for _, tt := range tests {
+		got := AnalyzeText("<i>Nyheter</i>", elements, tt.opts)
+		if got.Text != tt.text {
+			t.Errorf("%s: text got = %q, want = %q", tt.name, got.Text, tt.text)
+		}
+		if got.WordCount != tt.words {
+			t.Errorf("%s: word count got = %d, want = %d", tt.name, got.WordCount, tt.words)
+		}
+		if got.ReadingMinutes != 1 {
+			t.Errorf("%s: reading minutes got = %d, want = 1", tt.name, got.ReadingMinutes)
+		}
+		if got.Excerpt != "Hej du, hur mår du?" {
+			t.Errorf("%s: excerpt got = %q", tt.name, got.Excerpt)
+		}
+	}
+}
+
+func TestReadingMinutes(t *testing.T) {
+	tests := []struct {
+		words, wordsPerMinute, want int
+	}{
+		{0, 0, 0},
+		{1, 0, 1},
+		{200, 0, 1},
+		{201, 0, 2},
+		{250, 100, 3},
+	}
+	for _, tt := range tests {
+		if got := ReadingMinutes(tt.words, tt.wordsPerMinute); got != tt.want {
+			t.Errorf("ReadingMinutes(%d, %d) got = %d, want = %d", tt.words, tt.wordsPerMinute, got, tt.want)
+		}
+	}
+}
+
+func TestExcerpt(t *testing.T) {
+	paragraph := func(content string) []BodyElement {
+		return []BodyElement{&ParagraphElement{Content: content}}
+	}
+	tests := []struct {
+		content   string
+		maxLength int
+		want      string
+	}{
+		{"Short one.", 0, "Short one."},
+		{"Räksmörgås med ägg och majonnäs", 10, "Räksmörgås…"},
+		{"Räksmörgås med ägg", 14, "Räksmörgås med…"},
+		{"One, two, three", 9, "One, two…"},
+		{"Supercalifragilistic", 5, "Super…"},
+		{strings.Repeat("ord ", 100), 0, strings.TrimSpace(strings.Repeat("ord ", 50)) + "…"},
+	}
+	for _, tt := range tests {
+		got := Excerpt(paragraph(tt.content), tt.maxLength)
+		if got != tt.want {
+			t.Errorf("Excerpt(%q, %d) got = %q, want = %q", tt.content, tt.maxLength, got, tt.want)
+		}
+		if max := tt.maxLength; max > 0 && utf8.RuneCountInString(got) > max+1 {
+			t.Errorf("Excerpt(%q, %d) got = %q longer than the limit", tt.content, tt.maxLength, got)
+		}
+	}
+}
@@ -0,0 +1,311 @@
+package model
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jinzhu/now"
+	"golang.org/x/net/publicsuffix"
+)
+
+// GlobalDateLayouts is the registry key of the layouts tried for every publisher
+const GlobalDateLayouts = ""
+
+// we support all the formats from the time package
+var defaultDateLayouts = []string{
+	time.ANSIC,       // "Mon Jan _2 15:04:05 2006"
+	time.UnixDate,    // "Mon Jan _2 15:04:05 MST 2006"
+	time.RubyDate,    // "Mon Jan 02 15:04:05 -0700 2006"
+	time.RFC822,      // "02 Jan 06 15:04 MST"
+	time.RFC822Z,     // "02 Jan 06 15:04 -0700" // RFC822 with numeric zone
+	time.RFC850,      // "Monday, 02-Jan-06 15:04:05 MST"
+	time.RFC1123,     // "Mon, 02 Jan 2006 15:04:05 MST"
+	time.RFC1123Z,    // "Mon, 02 Jan 2006 15:04:05 -0700" // RFC1123 with numeric zone
+	time.RFC3339,     // "2006-01-02T15:04:05Z07:00"
+	time.RFC3339Nano, // "2006-01-02T15:04:05.999999999Z07:00"
+	time.Kitchen,     // "3:04PM"
+	time.Stamp,       // "Jan _2 15:04:05"
+	time.StampMilli,  // "Jan _2 15:04:05.000"
+	time.StampMicro,  // "Jan _2 15:04:05.000000"
+	time.StampNano,   // "Jan _2 15:04:05.000000000"
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+}
+
+// layouts used by particular publishers only
+var defaultPublisherDateLayouts = map[string][]string{
+	"svt.se": {"Mon Jan 02 2006 15:04:05 MST-0700"}, // Custom format for svt.se feed
+}
+
+// DateMatch describes how a date string was parsed
+type DateMatch struct {
+	Time time.Time
+	// Layout that matched, empty when the date was parsed in the relaxed mode
+	Layout string
+	// Key of the registry entry the layout comes from, GlobalDateLayouts for global layouts
+	Key string
+	// Relaxed is set when none of the layouts matched and the date was guessed by now.Parse
+	Relaxed bool
+	// ZoneGuessed is set when the date did not carry a reliable zone
+	// and the publisher's default location was applied
+	ZoneGuessed bool
+}
+
+// DateNormalisation holds the rules Normalize enforces
+type DateNormalisation struct {
+	// MaxFuture is how far in the future a date can be
+	MaxFuture time.Duration
+	// MinDate is the earliest plausible date
+	MinDate time.Time
+	// RejectRelaxed makes the dates none of the layouts matched invalid,
+	// otherwise they are only flagged with a warning
+	RejectRelaxed bool
+}
+
+// DefaultDateNormalisation are the rules used by registries created by NewDateLayouts
+var DefaultDateNormalisation = DateNormalisation{
+	MaxFuture: 48 * time.Hour,
+	MinDate:   time.Date(1990, time.January, 1, 0, 0, 0, 0, time.UTC),
+}
+
+// DateLayouts is a registry of date layouts.
+// Layouts are kept per publisher, the key is either the Publisher.ID
+// or the sourceURL host (see PublisherKey). Hosts fall back to their parent domains,
+// thus the layouts of svt.se are tried for nyheter.svt.se as well.
+type DateLayouts struct {
+	mu            sync.RWMutex
+	layouts       map[string][]string
+	locations     map[string]*time.Location
+	normalisation DateNormalisation
+}
+
+// DefaultDateLayouts is the registry used by MitemTiniest.Validate
+// and by the services unless configured otherwise.
+var DefaultDateLayouts = NewDateLayouts()
+
+// NewDateLayouts creates a registry with all the formats from the time package
+// and the publisher specific layouts we know about
+func NewDateLayouts() *DateLayouts {
+	dl := &DateLayouts{
+		layouts:       make(map[string][]string),
+		locations:     make(map[string]*time.Location),
+		normalisation: DefaultDateNormalisation,
+	}
+	dl.Register(GlobalDateLayouts, defaultDateLayouts...)
+	dl.Load(defaultPublisherDateLayouts)
+	return dl
+}
+
+// PublisherKey makes the registry key out of the publisher's sourceURL:
+// lowercased host without the www. prefix. The URL itself is returned
+// when it cannot be parsed.
+func PublisherKey(sourceURL string) string {
+	u, err := url.Parse(strings.TrimSpace(sourceURL))
+	if err != nil || len(u.Host) == 0 {
+		return strings.ToLower(sourceURL)
+	}
+	host := strings.ToLower(u.Hostname())
+	return strings.TrimPrefix(host, "www.")
+}
+
+// Register adds layouts for the given key, GlobalDateLayouts registers layouts for all publishers
+func (dl *DateLayouts) Register(key string, layouts ...string) {
+	dl.mu.Lock()
+	defer dl.mu.Unlock()
+	key = strings.ToLower(key)
+	for _, layout := range layouts {
+		if !contains(dl.layouts[key], layout) {
+			dl.layouts[key] = append(dl.layouts[key], layout)
+		}
+	}
+}
+
+// Load registers layouts read from config, the map is keyed by publisher key
+func (dl *DateLayouts) Load(layouts map[string][]string) {
+	for key, l := range layouts {
+		dl.Register(key, l...)
+	}
+}
+
+// Layouts returns layouts registered for the key
+func (dl *DateLayouts) Layouts(key string) []string {
+	dl.mu.RLock()
+	defer dl.mu.RUnlock()
+	return append([]string(nil), dl.layouts[strings.ToLower(key)]...)
+}
+
+// SetLocation sets the default zone applied to the publisher's dates that come without one.
+// GlobalDateLayouts sets the default for all publishers, UTC is used when none is set.
+func (dl *DateLayouts) SetLocation(key string, loc *time.Location) {
+	dl.mu.Lock()
+	defer dl.mu.Unlock()
+	dl.locations[strings.ToLower(key)] = loc
+}
+
+// LoadLocations sets default zones read from config, the map is keyed by publisher key
+// and holds IANA zone names i.e. Europe/Stockholm
+func (dl *DateLayouts) LoadLocations(zones map[string]string) error {
+	for key, zone := range zones {
+		loc, err := time.LoadLocation(zone)
+		if err != nil {
+			return fmt.Errorf("Unable to load zone %s for %s, error = %s", zone, key, err.Error())
+		}
+		dl.SetLocation(key, loc)
+	}
+	return nil
+}
+
+// Location returns the default zone of the first key (or its parent domain) that has one
+func (dl *DateLayouts) Location(keys ...string) *time.Location {
+	dl.mu.RLock()
+	defer dl.mu.RUnlock()
+	for _, key := range append(withParentDomains(keys), GlobalDateLayouts) {
+		if loc, ok := dl.locations[strings.ToLower(key)]; ok {
+			return loc
+		}
+	}
+	return time.UTC
+}
+
+// SetNormalisation changes the rules enforced by Normalize
+func (dl *DateLayouts) SetNormalisation(n DateNormalisation) {
+	dl.mu.Lock()
+	defer dl.mu.Unlock()
+	dl.normalisation = n
+}
+
+// Parse parses the date trying the layouts registered for the keys (and their parent domains) first,
+// then the global layouts. When nothing matches now.Parse is given a try.
+func (dl *DateLayouts) Parse(value string, keys ...string) (DateMatch, error) {
+	return dl.match(value, nil, keys)
+}
+
+// Normalize parses the date like Parse does and converts it to UTC.
+// Dates without a zone are read in the publisher's default location.
+// Dates missing the year or the day, dates too far in the future
+// and dates before DateNormalisation.MinDate are reported as errors,
+// dates parsed in the relaxed mode are flagged with a warning.
+// All returned errors are of *ValidationError type pointing at /date.
+func (dl *DateLayouts) Normalize(value string, keys ...string) (DateMatch, []error) {
+	loc := dl.Location(keys...)
+	match, err := dl.match(value, loc, keys)
+	if err != nil {
+		return match, []error{NewValidationError("/date", CodeInvalidFormat, value, err.Error())}
+	}
+	dl.mu.RLock()
+	n := dl.normalisation
+	dl.mu.RUnlock()
+
+	var ret []error
+	if match.Relaxed {
+		e := NewValidationError("/date", CodeDateRelaxed, value, "None of the layouts matched the date, it was guessed: "+value)
+		if !n.RejectRelaxed {
+			e.Severity = SeverityWarning
+		}
+		ret = append(ret, e)
+	} else if !layoutHasYear(match.Layout) || !layoutHasDay(match.Layout) {
+		ret = append(ret, NewValidationError("/date", CodeDateIncomplete, value,
+			fmt.Sprintf("Date %s misses the year or the day, layout = %s", value, match.Layout)))
+		return match, ret
+	}
+	match.Time = match.Time.UTC()
+	if n.MaxFuture > 0 && match.Time.After(time.Now().Add(n.MaxFuture)) {
+		ret = append(ret, NewValidationError("/date", CodeDateOutOfRange, value, "Date is too far in the future: "+value))
+	}
+	if !n.MinDate.IsZero() && match.Time.Before(n.MinDate) {
+		ret = append(ret, NewValidationError("/date", CodeDateOutOfRange, value,
+			fmt.Sprintf("Date %s is before %s", value, n.MinDate.Format(time.RFC3339))))
+	}
+	return match, ret
+}
+
+// match does the actual parsing, dates without a reliable zone are read in loc if given
+func (dl *DateLayouts) match(value string, loc *time.Location, keys []string) (DateMatch, error) {
+	value = strings.TrimSpace(value)
+	var tried []string
+	for _, key := range withParentDomains(keys) {
+		if len(key) > 0 {
+			tried = append(tried, key)
+		}
+	}
+	for _, key := range append(tried, GlobalDateLayouts) {
+		for _, layout := range dl.Layouts(key) {
+			if t, err := time.Parse(layout, value); err == nil {
+				ret := DateMatch{Time: t, Layout: layout, Key: strings.ToLower(key)}
+				if loc != nil && !zoneReliable(layout, t) {
+					ret.Time = inLocation(t, loc)
+					ret.ZoneGuessed = true
+				}
+				return ret, nil
+			}
+		}
+	}
+	t, err := now.Parse(value)
+	if err != nil {
+		return DateMatch{}, fmt.Errorf("None of the layouts registered for %v matched the date %s", tried, value)
+	}
+	ret := DateMatch{Time: t, Relaxed: true}
+	if loc != nil && t.Location() == time.Local {
+		ret.Time = inLocation(t, loc)
+		ret.ZoneGuessed = true
+	}
+	return ret, nil
+}
+
+// withParentDomains adds the parent domains after every key that is a host name,
+// i.e. nyheter.svt.se is followed by svt.se. It stops at the registrable domain,
+// so neither top level domains nor public suffixes like co.uk are added.
+func withParentDomains(keys []string) []string {
+	var ret []string
+	for _, key := range keys {
+		ret = append(ret, key)
+		registrable, err := publicsuffix.EffectiveTLDPlusOne(strings.ToLower(key))
+		if err != nil {
+			continue
+		}
+		for labels := strings.Split(key, "."); len(labels) > 1 && !strings.EqualFold(strings.Join(labels, "."), registrable); {
+			labels = labels[1:]
+			ret = append(ret, strings.Join(labels, "."))
+		}
+	}
+	return ret
+}
+
+// inLocation keeps the wall clock of t but moves it to loc
+func inLocation(t time.Time, loc *time.Location) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), loc)
+}
+
+func layoutHasYear(layout string) bool {
+	return strings.Contains(layout, "06")
+}
+
+func layoutHasDay(layout string) bool {
+	return strings.Contains(strings.Replace(layout, "2006", "", -1), "2")
+}
+
+// zoneReliable tells whether the parsed time carries a real zone.
+// Layouts without a zone and unknown zone abbreviations (which time.Parse
+// records with a zero offset) are not reliable.
+func zoneReliable(layout string, t time.Time) bool {
+	if strings.Contains(layout, "Z07") || strings.Contains(layout, "-07") {
+		return true
+	}
+	if !strings.Contains(layout, "MST") {
+		return false
+	}
+	name, offset := t.Zone()
+	return offset != 0 || name == "UTC" || name == "GMT" || name == "Z"
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,120 @@
+package model
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestWithParentDomains(t *testing.T) {
+	tests := []struct {
+		key  string
+		want []string
+	}{
+		{"svt.se", []string{"svt.se"}},
+		{"www.sport.svt.se", []string{"www.sport.svt.se", "sport.svt.se", "svt.se"}},
+		{"www.bbc.co.uk", []string{"www.bbc.co.uk", "bbc.co.uk"}},
+		{"bbc.co.uk", []string{"bbc.co.uk"}},
+		{"svt", []string{"svt"}},
+		{"", []string{""}},
+	}
+	for _, tt := range tests {
+		if got := withParentDomains([]string{tt.key}); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("withParentDomains(%q) got = %v, want = %v", tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestParsePublisherLayouts(t *testing.T) {
+	dl := NewDateLayouts()
+	const svtDate = "Thu Jan 02 2020 10:00:00 CET+0100"
+	tests := []struct {
+		key     string
+		wantKey string
+		wantErr bool
+	}{
+		{"svt.se", "svt.se", false},
+		{"nyheter.svt.se", "svt.se", false},
+		{"www.sport.svt.se", "svt.se", false},
+		{"notsvt.se", "", true},
+	}
+	for _, tt := range tests {
+		match, err := dl.Parse(svtDate, tt.key)
+		if tt.wantErr {
+			if err == nil && !match.Relaxed {
+				t.Errorf("Parse for %s matched layout %q, want none", tt.key, match.Layout)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Parse for %s unexpected error = %v", tt.key, err)
+			continue
+		}
+		if match.Key != tt.wantKey || match.Relaxed {
+			t.Errorf("Parse for %s got key = %q (relaxed %t), want = %q", tt.key, match.Key, match.Relaxed, tt.wantKey)
+		}
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	dl := NewDateLayouts()
+	stockholm, err := time.LoadLocation("Europe/Stockholm")
+	if err != nil {
+		t.Skipf("Zone database not available, error = %v", err)
+	}
+	dl.SetLocation("svt.se", stockholm)
+
+	tests := []struct {
+		value       string
+		key         string
+		want        time.Time
+		wantCode    string
+		zoneGuessed bool
+	}{
+		{"2020-01-02T10:00:00+01:00", "aftonbladet.se", time.Date(2020, 1, 2, 9, 0, 0, 0, time.UTC), "", false},
+		{"2020-07-02 10:00:00", "nyheter.svt.se", time.Date(2020, 7, 2, 8, 0, 0, 0, time.UTC), "", true},
+		{"2020-07-02 10:00:00", "aftonbladet.se", time.Date(2020, 7, 2, 10, 0, 0, 0, time.UTC), "", true},
+		{"3:04PM", "svt.se", time.Time{}, CodeDateIncomplete, false},
+		{"1980-01-02", "svt.se", time.Time{}, CodeDateOutOfRange, false},
+		{time.Now().Add(72 * time.Hour).Format(time.RFC3339), "svt.se", time.Time{}, CodeDateOutOfRange, false},
+	}
+	for _, tt := range tests {
+		match, errs := dl.Normalize(tt.value, tt.key)
+		if len(tt.wantCode) > 0 {
+			if len(errs) == 0 || AsValidationError(errs[0]).Code != tt.wantCode {
+				t.Errorf("Normalize(%q) errors = %v, want code = %s", tt.value, errs, tt.wantCode)
+			}
+			continue
+		}
+		if len(errs) > 0 {
+			t.Errorf("Normalize(%q) unexpected errors = %v", tt.value, errs)
+			continue
+		}
+		if !match.Time.Equal(tt.want) || match.Time.Location() != time.UTC {
+			t.Errorf("Normalize(%q) got = %v, want = %v", tt.value, match.Time, tt.want)
+		}
+		if match.ZoneGuessed != tt.zoneGuessed {
+			t.Errorf("Normalize(%q) zone guessed = %t, want = %t", tt.value, match.ZoneGuessed, tt.zoneGuessed)
+		}
+	}
+}
+
+func TestValidateWithDateLayouts(t *testing.T) {
+	mt := MitemTiniest{SourceURL: "https://example.com/a", Date: "02.01.2020 kl 10:00"}
+	hasDateError := func(errs []error) bool {
+		for _, err := range errs {
+			if AsValidationError(err).Path == "/date" {
+				return true
+			}
+		}
+		return false
+	}
+	if !hasDateError(mt.ValidateWith(nil, nil, nil)) {
+		t.Errorf("ValidateWith default layouts accepted date %q", mt.Date)
+	}
+	dl := NewDateLayouts()
+	dl.Register("example.com", "02.01.2006 kl 15:04")
+	if hasDateError(mt.ValidateWith(dl, nil, nil)) {
+		t.Errorf("ValidateWith custom layouts rejected date %q", mt.Date)
+	}
+}
@@ -2,28 +2,27 @@ package model
 
 import (
 	"encoding/json"
-	"errors"
 	"fmt"
 
-	"github.com/jinzhu/now"
+	log "github.com/Sirupsen/logrus"
+
+	"github.com/jedynykaban/testkeyholder/video"
 )
 
+// Body element types
 const (
-	bodyElementParagrahType = "paragraph"
-	bodyElementH1Type       = "h1"
-	bodyElementH2Type       = "h2"
-	bodyElementH3Type       = "h3"
-	bodyElementH4Type       = "h4"
-	bodyElementH5Type       = "h5"
-	bodyElementH6Type       = "h6"
-	bodyElementInfoType     = "info"
-	bodyElementSubheadType  = "subhead"
-	bodyElementImageType    = "image"
-	bodyElementVideoType    = "video"
-	bodyElementGalleryType  = "gallery"
-
-	supportedVideoTypeVimeo   = "vimeo"
-	supportedVideoTypeYoutube = "youtube"
+	ElementTypeParagraph = "paragraph"
+	ElementTypeH1        = "h1"
+	ElementTypeH2        = "h2"
+	ElementTypeH3        = "h3"
+	ElementTypeH4        = "h4"
+	ElementTypeH5        = "h5"
+	ElementTypeH6        = "h6"
+	ElementTypeInfo      = "info"
+	ElementTypeSubhead   = "subhead"
+	ElementTypeImage     = "image"
+	ElementTypeVideo     = "video"
+	ElementTypeGallery   = "gallery"
 )
 
 // MitemTiniest contains mitem metadata
@@ -73,8 +72,10 @@ type bodyImageTiniest struct {
 
 type bodyVideoTiniest struct {
 	bodyElement
-	Source    string `json:"source"`
-	VideoType string `json:"videoType"`
+	Source       string `json:"source"`
+	VideoType    string `json:"videoType"`
+	EmbedURL     string `json:"embedURL,omitempty"`
+	ThumbnailURL string `json:"thumbnailURL,omitempty"`
 }
 
 type bodyGalleryTiniest struct {
@@ -94,90 +95,111 @@ type MetaTiniest struct {
 	MosaiqPrimary bool   `json:"mosaiqPrimary,omitempty"`
 	UserEdited    bool   `json:"userEdited,omitempty"`
 	Tags          []Tag  `json:"tags,omitempty"`
+	// MainImageFallback is set when the mainimage was missing and picked from the body
+	MainImageFallback bool `json:"mainImageFallback,omitempty"`
 }
 
 // Validate checks agains all mandatory fields in tiniest mitemTiniest
-// and also validate it's body elements
+// and also validate it's body elements.
+// The rules of DefaultValidationProfiles are applied on top, see ValidateWith.
+// All returned errors are of *ValidationError type.
 func (m *MitemTiniest) Validate() []error {
+	return m.ValidateWith(DefaultDateLayouts, DefaultValidationProfiles, nil)
+}
+
+// ValidateWith checks the mandatory fields and the body elements like Validate
+// and then applies the matching profiles of the registry.
+// The date is parsed with the layouts of dl, DefaultDateLayouts when nil.
+// data is the mitem as passed in, the profile rules may refer to the fields MitemTiniest does not hold,
+// it may be empty though.
+func (m *MitemTiniest) ValidateWith(dl *DateLayouts, vp *ValidationProfiles, data json.RawMessage) []error {
+	if dl == nil {
+		dl = DefaultDateLayouts
+	}
+	ret := m.validateMandatory(dl)
+	if vp != nil {
+		ret = append(ret, vp.Validate(m, data)...)
+	}
+	return ret
+}
+
+func (m *MitemTiniest) validateMandatory(dl *DateLayouts) []error {
 	var ret []error
 	if len(m.SourceURL) == 0 {
-		ret = append(ret, errors.New("Mandatory field sourceURL is empty"))
+		ret = append(ret, NewValidationError("/sourceURL", CodeRequired, nil, "Mandatory field sourceURL is empty"))
 	}
 	if len(m.Date) == 0 {
-		ret = append(ret, errors.New("Mandatory field date is empty"))
+		ret = append(ret, NewValidationError("/date", CodeRequired, nil, "Mandatory field date is empty"))
 	} else {
-		// TODO: We support two formats, add more
-		if _, err := now.Parse(m.Date); err != nil {
-			ret = append(ret, errors.New("Mandatory field date is in unsupported format: "+err.Error()))
+		match, err := dl.Parse(m.Date, PublisherKey(m.SourceURL))
+		if err != nil {
+			ret = append(ret, NewValidationError("/date", CodeInvalidFormat, m.Date, "Mandatory field date is in unsupported format: "+err.Error()))
+		} else {
+			log.WithFields(log.Fields{"date": m.Date, "layout": match.Layout, "key": match.Key, "relaxed": match.Relaxed}).Debug("Mitem date matched")
 		}
 	}
 	if len(m.Type) == 0 {
-		ret = append(ret, errors.New("Mandatory field type is empty"))
+		ret = append(ret, NewValidationError("/type", CodeRequired, nil, "Mandatory field type is empty"))
 	}
 	if len(m.LicenseType) == 0 {
-		ret = append(ret, errors.New("Mandatory field license type is empty"))
-	} else {
-		const e string = "editorial"
-		const s string = "sponsored"
-		if m.LicenseType != e && m.LicenseType != s {
-			m := fmt.Sprintf("Unsupported license type got = %s, want = %s or %s", m.LicenseType, e, s)
-			ret = append(ret, errors.New(m))
-		}
+		ret = append(ret, NewValidationError("/licensetype", CodeRequired, nil, "Mandatory field license type is empty"))
 	}
 	if len(m.MainImage.Source) == 0 {
-		ret = append(ret, errors.New("Mandatory field mainimage.source is empty"))
+		ret = append(ret, NewValidationError("/mainimage/source", CodeRequired, nil, "Mandatory field mainimage.source is empty"))
 	}
 	if len(m.Headline) == 0 {
-		ret = append(ret, errors.New("Mandatory field headline is empty"))
+		ret = append(ret, NewValidationError("/headline", CodeRequired, nil, "Mandatory field headline is empty"))
 	}
 	if len(m.Body) == 0 {
-		ret = append(ret, errors.New("Mandatory field body is empty"))
+		ret = append(ret, NewValidationError("/body", CodeRequired, nil, "Mandatory field body is empty"))
 	} else {
-		ret = append(ret, validateBody(m.Body)...)
+		ret = append(ret, validateBody(m.Body, "/body")...)
 	}
 	return ret
 }
 
-func validateBody(datas []json.RawMessage) []error {
+// validateBody validates body elements, path is a JSON pointer to the body itself
+func validateBody(datas []json.RawMessage, path string) []error {
 	var ret []error
-	for _, data := range datas {
+	for idx, data := range datas {
+		elementPath := JSONPointer(path, idx)
 		var element bodyCommonTiniest
 		err := json.Unmarshal(data, &element)
 		if err != nil {
-			ret = append(ret, errors.New("Unable to unmarshal body element"))
+			ret = append(ret, NewValidationError(elementPath, CodeMalformed, nil, "Unable to unmarshal body element"))
 		} else {
 			if len(element.Type) == 0 {
-				ret = append(ret, errors.New("Mandatory field type is empty in body element"))
+				ret = append(ret, NewValidationError(elementPath+"/type", CodeRequired, nil, "Mandatory field type is empty in body element"))
 			} else {
-				ret = append(ret, validateBodyElement(data, element.Type)...)
+				ret = append(ret, validateBodyElement(data, element.Type, elementPath)...)
 			}
 		}
 	}
 	return ret
 }
 
-func validateBodyElement(data json.RawMessage, elementType string) []error {
+func validateBodyElement(data json.RawMessage, elementType string, path string) []error {
 	var ret []error
 	switch elementType {
-	case bodyElementParagrahType,
-		bodyElementH1Type,
-		bodyElementH2Type,
-		bodyElementH3Type,
-		bodyElementH4Type,
-		bodyElementH5Type,
-		bodyElementH6Type,
-		bodyElementInfoType,
-		bodyElementSubheadType:
-		ret = append(ret, validateBodyElementCommon(data, elementType)...)
-
-	case bodyElementImageType:
-		ret = append(ret, validateBodyElementImage(data, elementType)...)
-
-	case bodyElementVideoType:
-		ret = append(ret, validateBodyElementVideo(data, elementType)...)
-
-	case bodyElementGalleryType:
-		ret = append(ret, validateBodyElementGallery(data, elementType)...)
+	case ElementTypeParagraph,
+		ElementTypeH1,
+		ElementTypeH2,
+		ElementTypeH3,
+		ElementTypeH4,
+		ElementTypeH5,
+		ElementTypeH6,
+		ElementTypeInfo,
+		ElementTypeSubhead:
+		ret = append(ret, validateBodyElementCommon(data, elementType, path)...)
+
+	case ElementTypeImage:
+		ret = append(ret, validateBodyElementImage(data, elementType, path)...)
+
+	case ElementTypeVideo:
+		ret = append(ret, validateBodyElementVideo(data, elementType, path)...)
+
+	case ElementTypeGallery:
+		ret = append(ret, validateBodyElementGallery(data, elementType, path)...)
 
 	default:
 		// ignore other element types in validation
@@ -185,12 +207,16 @@ func validateBodyElement(data json.RawMessage, elementType string) []error {
 	return ret
 }
 
-func validateBodyElementCommon(data json.RawMessage, elementType string) []error {
+func unmarshalElementError(elementType string, path string) error {
+	return NewValidationError(path, CodeMalformed, nil, fmt.Sprintf("Unable to unmarshal element of type: %v", elementType))
+}
+
+func validateBodyElementCommon(data json.RawMessage, elementType string, path string) []error {
 	var ret []error
 	var element bodyCommonTiniest
 	err := json.Unmarshal(data, &element)
 	if err != nil {
-		ret = append(ret, fmt.Errorf("Unable to unmarshal element of type: %v", elementType))
+		ret = append(ret, unmarshalElementError(elementType, path))
 	} else {
 		// TODO: Maybe process paragraphs with empty content ?
 		// if len(element.Content) == 0 {
@@ -200,53 +226,60 @@ func validateBodyElementCommon(data json.RawMessage, elementType string) []error
 	return ret
 }
 
-func validateBodyElementImage(data json.RawMessage, elementType string) []error {
+func validateBodyElementImage(data json.RawMessage, elementType string, path string) []error {
 	var ret []error
 	var element bodyImageTiniest
 	err := json.Unmarshal(data, &element)
 	if err != nil {
-		ret = append(ret, fmt.Errorf("Unable to unmarshal element of type: %v", elementType))
+		ret = append(ret, unmarshalElementError(elementType, path))
 	} else {
 		if len(element.Source) == 0 {
-			ret = append(ret, fmt.Errorf("Mandatory field source is empty in element of type: %v", elementType))
+			ret = append(ret, NewValidationError(path+"/source", CodeRequired, nil,
+				fmt.Sprintf("Mandatory field source is empty in element of type: %v", elementType)))
 		}
 	}
 	return ret
 }
 
-func validateBodyElementVideo(data json.RawMessage, elementType string) []error {
+func validateBodyElementVideo(data json.RawMessage, elementType string, path string) []error {
 	var ret []error
 	var element bodyVideoTiniest
 	err := json.Unmarshal(data, &element)
 	if err != nil {
-		ret = append(ret, fmt.Errorf("Unable to unmarshal element of type: %v", elementType))
+		ret = append(ret, unmarshalElementError(elementType, path))
 	} else {
 		if len(element.Source) == 0 {
-			ret = append(ret, fmt.Errorf("Mandatory field source is empty in element of type: %v", elementType))
+			ret = append(ret, NewValidationError(path+"/source", CodeRequired, nil,
+				fmt.Sprintf("Mandatory field source is empty in element of type: %v", elementType)))
 		}
 		if len(element.VideoType) == 0 {
-			ret = append(ret, fmt.Errorf("Mandatory field videoType is empty in element of type: %v", elementType))
-		} else {
-			if element.VideoType != supportedVideoTypeVimeo && element.VideoType != supportedVideoTypeYoutube {
-				ret = append(ret, fmt.Errorf("Mandatory field videoType has invalid content (%v) in element of type: %v", element.VideoType, elementType))
-
+			ret = append(ret, NewValidationError(path+"/videoType", CodeRequired, nil,
+				fmt.Sprintf("Mandatory field videoType is empty in element of type: %v", elementType)))
+		} else if !video.IsSupported(element.VideoType) {
+			ret = append(ret, NewValidationError(path+"/videoType", CodeUnsupportedValue, element.VideoType,
+				fmt.Sprintf("Mandatory field videoType has invalid content (%v) in element of type: %v", element.VideoType, elementType)))
+		} else if len(element.Source) > 0 {
+			if _, err := video.Parse(element.VideoType, element.Source); err != nil {
+				ret = append(ret, NewValidationError(path+"/source", CodeInvalidFormat, element.Source,
+					fmt.Sprintf("Field source is neither %v video ID nor its URL in element of type: %v", element.VideoType, elementType)))
 			}
 		}
 	}
 	return ret
 }
 
-func validateBodyElementGallery(data json.RawMessage, elementType string) []error {
+func validateBodyElementGallery(data json.RawMessage, elementType string, path string) []error {
 	var ret []error
 	var element bodyGalleryTiniest
 	err := json.Unmarshal(data, &element)
 	if err != nil {
-		ret = append(ret, fmt.Errorf("Unable to unmarshal element of type: %v", elementType))
+		ret = append(ret, unmarshalElementError(elementType, path))
 	} else {
 		if len(element.Body) == 0 {
-			ret = append(ret, fmt.Errorf("Mandatory field body is empty in element of type: %v", elementType))
+			ret = append(ret, NewValidationError(path+"/body", CodeRequired, nil,
+				fmt.Sprintf("Mandatory field body is empty in element of type: %v", elementType)))
 		} else {
-			ret = append(ret, validateBody(element.Body)...)
+			ret = append(ret, validateBody(element.Body, path+"/body")...)
 		}
 	}
 	return ret
@@ -82,6 +82,16 @@ type Meta struct {
 
 	// Tags represents a collection of tags that were attached to a mitem
 	Tags []Tag `json:"tags, omitempty"`
+
+	// WordCount, ReadingTime (in minutes) and Excerpt are derived from the body
+	// along with the image captions, see AnalyzeText
+	WordCount   int    `json:"wordCount,omitempty"`
+	ReadingTime int    `json:"readingTime,omitempty"`
+	Excerpt     string `json:"excerpt,omitempty"`
+
+	// MainImageFallback indicates that the feed did not provide the main image
+	// and it was picked from the body
+	MainImageFallback bool `json:"mainImageFallback,omitempty"`
 }
 
 // Tag represents a tag attached to a mitem
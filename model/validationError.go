@@ -0,0 +1,97 @@
+package model
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Severity tells how serious a validation problem is
+type Severity string
+
+const (
+	// SeverityError makes the mitem invalid
+	SeverityError Severity = "error"
+	// SeverityWarning is reported but does not make the mitem invalid
+	SeverityWarning Severity = "warning"
+)
+
+// Validation error codes, these are stable and meant to be consumed by machines
+const (
+	// CodeEmpty means that an empty mitem was passed in
+	CodeEmpty = "empty"
+	// CodeMalformed means that the mitem or one of its parts could not be unmarshalled
+	CodeMalformed = "malformed"
+	// CodeRequired means that a mandatory field is missing or empty
+	CodeRequired = "required"
+	// CodeInvalidFormat means that a field is present but its format is not supported
+	CodeInvalidFormat = "invalid_format"
+	// CodeUnsupportedValue means that a field holds a value outside of the allowed set
+	CodeUnsupportedValue = "unsupported_value"
+	// CodeDateIncomplete means that a date misses the year or the day
+	CodeDateIncomplete = "date_incomplete"
+	// CodeDateOutOfRange means that a date is too far in the future or before a plausible epoch
+	CodeDateOutOfRange = "date_out_of_range"
+	// CodeDateRelaxed means that none of the layouts matched and the date was guessed
+	CodeDateRelaxed = "date_relaxed"
+)
+
+// ValidationError describes a single problem found while validating a mitem
+type ValidationError struct {
+	// Path is a JSON pointer (RFC 6901) to the offending field i.e. /body/3/body/1/source
+	Path     string      `json:"path"`
+	Code     string      `json:"code"`
+	Severity Severity    `json:"severity"`
+	Value    interface{} `json:"value,omitempty"`
+	Message  string      `json:"message"`
+}
+
+// Error implements error interface, it returns the human readable message only
+func (e *ValidationError) Error() string {
+	return e.Message
+}
+
+// NewValidationError creates an error of SeverityError for the given JSON pointer
+func NewValidationError(path, code string, value interface{}, message string) *ValidationError {
+	return &ValidationError{
+		Path:     path,
+		Code:     code,
+		Severity: SeverityError,
+		Value:    value,
+		Message:  message,
+	}
+}
+
+// AsValidationError converts any error into ValidationError.
+// Errors that are not validation errors are reported as malformed at the document root.
+func AsValidationError(err error) *ValidationError {
+	if ve, ok := err.(*ValidationError); ok {
+		return ve
+	}
+	return NewValidationError("", CodeMalformed, nil, err.Error())
+}
+
+// HasErrors tells whether the list contains at least one error of SeverityError
+func HasErrors(errs []error) bool {
+	for _, err := range errs {
+		if AsValidationError(err).Severity != SeverityWarning {
+			return true
+		}
+	}
+	return false
+}
+
+var pointerEscaper = strings.NewReplacer("~", "~0", "/", "~1")
+
+// JSONPointer appends tokens to the JSON pointer escaping them as required by RFC 6901
+func JSONPointer(base string, tokens ...interface{}) string {
+	ret := base
+	for _, token := range tokens {
+		switch t := token.(type) {
+		case int:
+			ret += "/" + strconv.Itoa(t)
+		case string:
+			ret += "/" + pointerEscaper.Replace(t)
+		}
+	}
+	return ret
+}
@@ -0,0 +1,89 @@
+package model
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestJSONPointer(t *testing.T) {
+	tests := []struct {
+		base   string
+		tokens []interface{}
+		want   string
+	}{
+		{"", nil, ""},
+		{"", []interface{}{"body", 3, "source"}, "/body/3/source"},
+		{"/body/1", []interface{}{"body", 0}, "/body/1/body/0"},
+		{"", []interface{}{"a/b", "c~d"}, "/a~1b/c~0d"},
+	}
+	for _, tt := range tests {
+		if got := JSONPointer(tt.base, tt.tokens...); got != tt.want {
+			t.Errorf("JSONPointer(%q, %v) got = %q, want = %q", tt.base, tt.tokens, got, tt.want)
+		}
+	}
+}
+
+func TestAsValidationError(t *testing.T) {
+	ve := NewValidationError("/date", CodeInvalidFormat, "x", "bad date")
+	if AsValidationError(ve) != ve {
+		t.Errorf("AsValidationError changed the validation error")
+	}
+	got := AsValidationError(errors.New("boom"))
+	if got.Path != "" || got.Code != CodeMalformed || got.Severity != SeverityError || got.Message != "boom" {
+		t.Errorf("AsValidationError of plain error got = %+v", got)
+	}
+
+	warning := NewValidationError("/date", CodeDateRelaxed, nil, "guessed")
+	warning.Severity = SeverityWarning
+	if HasErrors([]error{warning}) {
+		t.Errorf("HasErrors of warnings only got = true")
+	}
+	if !HasErrors([]error{warning, ve}) {
+		t.Errorf("HasErrors of warning and error got = false")
+	}
+}
+
+func TestValidatePaths(t *testing.T) {
+	data := json.RawMessage(`{
+		"sourceURL": "https://www.svt.se/a",
+		"date": "not a date at all",
+		"type": "article",
+		"licensetype": "editorial",
+		"mainimage": {"caption": "no source"},
+		"headline": "Headline",
+		"body": [
+			{"type": "paragraph", "content": "fine"},
+			{"content": "no type"},
+			{"type": "image"},
+			{"type": "video", "source": "abc", "videoType": "realplayer"},
+			{"type": "gallery", "body": [{"type": "image", "source": "a.jpg"}, {"type": "video"}]},
+			"not an object"
+		]
+	}`)
+	var mt MitemTiniest
+	if err := json.Unmarshal(data, &mt); err != nil {
+		t.Fatalf("Unable to unmarshal mitem, error = %v", err)
+	}
+	var got []string
+	for _, err := range mt.ValidateWith(nil, nil, data) {
+		ve := AsValidationError(err)
+		got = append(got, ve.Path+" "+ve.Code)
+	}
+	sort.Strings(got)
+	want := []string{
+		"/body/1/type " + CodeRequired,
+		"/body/2/source " + CodeRequired,
+		"/body/3/videoType " + CodeUnsupportedValue,
+		"/body/4/body/1/source " + CodeRequired,
+		"/body/4/body/1/videoType " + CodeRequired,
+		"/body/5 " + CodeMalformed,
+		"/date " + CodeInvalidFormat,
+		"/mainimage/source " + CodeRequired,
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Validate got:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
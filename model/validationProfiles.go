@@ -0,0 +1,379 @@
+package model
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strconv"
+	"strings"
+	"sync"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// CodeBodyConstraint means that the body holds too few or too many elements of a type
+const CodeBodyConstraint = "body_constraint"
+
+// DefaultProfileName is the name of the profile applied to all the mitems
+const DefaultProfileName = "default"
+
+// ValidationProfile is a declarative set of rules applied to the mitems selected by Match.
+// Profiles are read from YAML or JSON, i.e.
+//
+//	profiles:
+//	  - name: sponsored
+//	    match:
+//	      licensetypes: [sponsored]
+//	    rules:
+//	      - field: /licensetext
+//	        required: true
+//	      - field: /licensepromo
+//	        required: true
+//	        severity: warning
+//	  - name: video
+//	    match:
+//	      types: [video]
+//	    rules:
+//	      - body:
+//	          element: video
+//	          min: 1
+type ValidationProfile struct {
+	Name  string           `json:"name" yaml:"name"`
+	Match ProfileMatch     `json:"match" yaml:"match"`
+	Rules []ValidationRule `json:"rules" yaml:"rules"`
+}
+
+// ProfileMatch selects the mitems the profile applies to, an empty list matches any value.
+// Publishers are compared with the PublisherKey of the mitem's sourceURL.
+type ProfileMatch struct {
+	Types        []string `json:"types,omitempty" yaml:"types,omitempty"`
+	LicenseTypes []string `json:"licensetypes,omitempty" yaml:"licensetypes,omitempty"`
+	Publishers   []string `json:"publishers,omitempty" yaml:"publishers,omitempty"`
+}
+
+// ValidationRule checks either a field or the body of the mitem
+type ValidationRule struct {
+	// Field is a JSON pointer to the checked field i.e. /mainimage/caption
+	Field string `json:"field,omitempty" yaml:"field,omitempty"`
+	// Required rejects missing, null and empty fields
+	Required bool `json:"required,omitempty" yaml:"required,omitempty"`
+	// Allowed lists the values the field may hold, a missing field is left to Required
+	Allowed []string `json:"allowed,omitempty" yaml:"allowed,omitempty"`
+	// Body constrains the number of elements of a type
+	Body *BodyRule `json:"body,omitempty" yaml:"body,omitempty"`
+	// Severity of the reported problems, SeverityError when empty
+	Severity Severity `json:"severity,omitempty" yaml:"severity,omitempty"`
+	// Message replaces the generated message
+	Message string `json:"message,omitempty" yaml:"message,omitempty"`
+}
+
+// BodyRule constrains the number of the body elements of the type, gallery children included
+type BodyRule struct {
+	Element string `json:"element" yaml:"element"`
+	Min     int    `json:"min,omitempty" yaml:"min,omitempty"`
+	// Max is not checked when nil, 0 forbids the element
+	Max *int `json:"max,omitempty" yaml:"max,omitempty"`
+}
+
+// validationProfilesFile is the structure of the profiles file
+type validationProfilesFile struct {
+	Profiles []ValidationProfile `json:"profiles" yaml:"profiles"`
+}
+
+// defaultValidationProfiles hold the rules that used to be hardcoded in MitemTiniest.Validate
+var defaultValidationProfiles = []ValidationProfile{
+	{
+		Name: DefaultProfileName,
+		Rules: []ValidationRule{
+			{Field: "/licensetype", Allowed: []string{"editorial", "sponsored"}},
+		},
+	},
+}
+
+// ValidationProfiles is a registry of validation profiles, a profile replaces the one of the same name
+type ValidationProfiles struct {
+	mu       sync.RWMutex
+	profiles []ValidationProfile
+}
+
+// DefaultValidationProfiles is the registry used by MitemTiniest.Validate
+// and by the services unless configured otherwise.
+var DefaultValidationProfiles = NewValidationProfiles()
+
+// NewValidationProfiles creates a registry holding the default profile
+func NewValidationProfiles() *ValidationProfiles {
+	vp := &ValidationProfiles{}
+	if err := vp.Add(defaultValidationProfiles...); err != nil {
+		panic(err)
+	}
+	return vp
+}
+
+// ParseValidationProfiles reads the profiles from YAML or JSON document
+func ParseValidationProfiles(data []byte) ([]ValidationProfile, error) {
+	var file validationProfilesFile
+	var err error
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '{' {
+		err = json.Unmarshal(trimmed, &file)
+	} else {
+		err = yaml.Unmarshal(data, &file)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Unable to parse validation profiles, error = %s", err.Error())
+	}
+	return file.Profiles, nil
+}
+
+// Add registers the profiles, it fails on the first invalid one leaving the registry untouched
+func (vp *ValidationProfiles) Add(profiles ...ValidationProfile) error {
+	for _, p := range profiles {
+		if err := p.check(); err != nil {
+			return err
+		}
+	}
+	vp.mu.Lock()
+	defer vp.mu.Unlock()
+	for _, p := range profiles {
+		replaced := false
+		for idx := range vp.profiles {
+			if vp.profiles[idx].Name == p.Name {
+				vp.profiles[idx] = p
+				replaced = true
+			}
+		}
+		if !replaced {
+			vp.profiles = append(vp.profiles, p)
+		}
+	}
+	return nil
+}
+
+// Load parses the profiles from YAML or JSON document and registers them
+func (vp *ValidationProfiles) Load(data []byte) error {
+	profiles, err := ParseValidationProfiles(data)
+	if err != nil {
+		return err
+	}
+	return vp.Add(profiles...)
+}
+
+// LoadFiles loads the profiles from the files
+func (vp *ValidationProfiles) LoadFiles(files ...string) error {
+	for _, file := range files {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			return fmt.Errorf("Unable to read validation profiles %s, error = %s", file, err.Error())
+		}
+		if err := vp.Load(data); err != nil {
+			return fmt.Errorf("%s: %s", file, err.Error())
+		}
+	}
+	return nil
+}
+
+// Profiles returns all the registered profiles
+func (vp *ValidationProfiles) Profiles() []ValidationProfile {
+	vp.mu.RLock()
+	defer vp.mu.RUnlock()
+	return append([]ValidationProfile(nil), vp.profiles...)
+}
+
+// Match returns the profiles applying to the mitem in the registration order
+func (vp *ValidationProfiles) Match(m *MitemTiniest) []ValidationProfile {
+	var ret []ValidationProfile
+	for _, p := range vp.Profiles() {
+		if p.Match.matches(m) {
+			ret = append(ret, p)
+		}
+	}
+	return ret
+}
+
+// Validate checks the mitem against the rules of all the matching profiles.
+// Fields are looked up in data, the mitem itself is used when data is empty.
+// All returned errors are of *ValidationError type.
+func (vp *ValidationProfiles) Validate(m *MitemTiniest, data json.RawMessage) []error {
+	profiles := vp.Match(m)
+	if len(profiles) == 0 {
+		return nil
+	}
+	var doc interface{}
+	if len(data) == 0 {
+		var err error
+		if data, err = json.Marshal(m); err != nil {
+			return []error{NewValidationError("", CodeMalformed, nil, "Unable to marshal passed mitem")}
+		}
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return []error{NewValidationError("", CodeMalformed, nil, "Unable to unmarshal passed mitem")}
+	}
+	var ret []error
+	for _, p := range profiles {
+		for _, rule := range p.Rules {
+			for _, ve := range rule.validate(doc, m) {
+				if len(rule.Severity) > 0 {
+					ve.Severity = rule.Severity
+				}
+				if len(rule.Message) > 0 {
+					ve.Message = rule.Message
+				}
+				ret = append(ret, ve)
+			}
+		}
+	}
+	return ret
+}
+
+func (p ValidationProfile) check() error {
+	if len(p.Name) == 0 {
+		return fmt.Errorf("Validation profile has no name")
+	}
+	for idx, rule := range p.Rules {
+		if err := rule.check(); err != nil {
+			return fmt.Errorf("Invalid rule %d of validation profile %s, error = %s", idx, p.Name, err.Error())
+		}
+	}
+	return nil
+}
+
+func (pm ProfileMatch) matches(m *MitemTiniest) bool {
+	return matchesValue(pm.Types, m.Type) &&
+		matchesValue(pm.LicenseTypes, m.LicenseType) &&
+		matchesValue(pm.Publishers, PublisherKey(m.SourceURL))
+}
+
+func matchesValue(values []string, value string) bool {
+	if len(values) == 0 {
+		return true
+	}
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r ValidationRule) check() error {
+	switch {
+	case len(r.Field) == 0 && r.Body == nil:
+		return fmt.Errorf("Rule checks neither a field nor the body")
+	case len(r.Field) > 0 && r.Body != nil:
+		return fmt.Errorf("Rule checks both a field and the body")
+	case len(r.Field) > 0 && !strings.HasPrefix(r.Field, "/"):
+		return fmt.Errorf("Field %s is not a JSON pointer", r.Field)
+	case len(r.Field) > 0 && !r.Required && len(r.Allowed) == 0:
+		return fmt.Errorf("Rule of field %s is neither required nor lists allowed values", r.Field)
+	case r.Body != nil && len(r.Body.Element) == 0:
+		return fmt.Errorf("Body rule has no element type")
+	case r.Body != nil && r.Body.Max != nil && *r.Body.Max < r.Body.Min:
+		return fmt.Errorf("Body rule of %s has max below min", r.Body.Element)
+	case r.Severity != "" && r.Severity != SeverityError && r.Severity != SeverityWarning:
+		return fmt.Errorf("Unknown severity %s", r.Severity)
+	}
+	return nil
+}
+
+func (r ValidationRule) validate(doc interface{}, m *MitemTiniest) []*ValidationError {
+	var ret []*ValidationError
+	if r.Body != nil {
+		var count int
+		countElements(m.Body, r.Body.Element, &count)
+		if count < r.Body.Min {
+			ret = append(ret, NewValidationError("/body", CodeBodyConstraint, count,
+				fmt.Sprintf("Body holds %d element(s) of type %s, want at least %d", count, r.Body.Element, r.Body.Min)))
+		}
+		if r.Body.Max != nil && count > *r.Body.Max {
+			ret = append(ret, NewValidationError("/body", CodeBodyConstraint, count,
+				fmt.Sprintf("Body holds %d element(s) of type %s, want at most %d", count, r.Body.Element, *r.Body.Max)))
+		}
+		return ret
+	}
+	value, found := lookupPointer(doc, r.Field)
+	if isEmptyValue(value) {
+		if r.Required {
+			ret = append(ret, NewValidationError(r.Field, CodeRequired, nil, fmt.Sprintf("Mandatory field %s is empty", fieldName(r.Field))))
+		}
+		return ret
+	}
+	if len(r.Allowed) > 0 && found {
+		str := valueString(value)
+		if !containsFold(r.Allowed, str) {
+			msg := fmt.Sprintf("Unsupported %s got = %s, want one of = %s", fieldName(r.Field), str, strings.Join(r.Allowed, ", "))
+			ret = append(ret, NewValidationError(r.Field, CodeUnsupportedValue, value, msg))
+		}
+	}
+	return ret
+}
+
+// countElements counts the body elements of the type, gallery children included
+func countElements(datas []json.RawMessage, elementType string, count *int) {
+	for _, data := range datas {
+		var element bodyGalleryTiniest
+		if err := json.Unmarshal(data, &element); err != nil {
+			continue
+		}
+		if element.Type == elementType {
+			*count++
+		}
+		if element.Type == ElementTypeGallery {
+			countElements(element.Body, elementType, count)
+		}
+	}
+}
+
+// lookupPointer resolves the JSON pointer (RFC 6901) against the decoded document
+func lookupPointer(doc interface{}, pointer string) (interface{}, bool) {
+	current := doc
+	for _, token := range strings.Split(pointer, "/")[1:] {
+		token = strings.Replace(strings.Replace(token, "~1", "/", -1), "~0", "~", -1)
+		switch node := current.(type) {
+		case map[string]interface{}:
+			value, ok := node[token]
+			if !ok {
+				return nil, false
+			}
+			current = value
+		case []interface{}:
+			idx, err := strconv.Atoi(token)
+			if err != nil || idx < 0 || idx >= len(node) {
+				return nil, false
+			}
+			current = node[idx]
+		default:
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+func isEmptyValue(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return true
+	case string:
+		return len(strings.TrimSpace(v)) == 0
+	case []interface{}:
+		return len(v) == 0
+	case map[string]interface{}:
+		return len(v) == 0
+	}
+	return false
+}
+
+func valueString(value interface{}) string {
+	if s, ok := value.(string); ok {
+		return s
+	}
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(encoded)
+}
+
+// fieldName makes the dotted field name out of the JSON pointer i.e. mainimage.source
+func fieldName(pointer string) string {
+	return strings.Replace(strings.TrimPrefix(pointer, "/"), "/", ".", -1)
+}
@@ -0,0 +1,142 @@
+package model
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+const testProfiles = `
+profiles:
+  - name: sponsored
+    match:
+      licensetypes: [sponsored]
+    rules:
+      - field: /licensetext
+        required: true
+      - field: /licensepromo
+        required: true
+        severity: warning
+  - name: video
+    match:
+      types: [video]
+    rules:
+      - body:
+          element: video
+          min: 1
+          max: 1
+  - name: svt
+    match:
+      publishers: [svt.se]
+    rules:
+      - field: /mainimage/caption
+        required: true
+        message: SVT images need captions
+`
+
+func TestParseValidationProfiles(t *testing.T) {
+	fromYAML, err := ParseValidationProfiles([]byte(testProfiles))
+	if err != nil {
+		t.Fatalf("ParseValidationProfiles of YAML unexpected error = %v", err)
+	}
+	data, _ := json.Marshal(validationProfilesFile{Profiles: fromYAML})
+	fromJSON, err := ParseValidationProfiles(data)
+	if err != nil {
+		t.Fatalf("ParseValidationProfiles of JSON unexpected error = %v", err)
+	}
+	if !reflect.DeepEqual(fromYAML, fromJSON) {
+		t.Errorf("ParseValidationProfiles YAML got = %+v, JSON got = %+v", fromYAML, fromJSON)
+	}
+	if len(fromYAML) != 3 || fromYAML[1].Rules[0].Body == nil || *fromYAML[1].Rules[0].Body.Max != 1 {
+		t.Errorf("ParseValidationProfiles got = %+v", fromYAML)
+	}
+	if _, err := ParseValidationProfiles([]byte("profiles: [")); err == nil {
+		t.Errorf("ParseValidationProfiles of malformed YAML expected error")
+	}
+}
+
+func TestValidationProfilesAdd(t *testing.T) {
+	zero := 0
+	invalid := []ValidationProfile{
+		{Rules: []ValidationRule{{Field: "/a", Required: true}}},
+		{Name: "empty rule", Rules: []ValidationRule{{}}},
+		{Name: "both", Rules: []ValidationRule{{Field: "/a", Required: true, Body: &BodyRule{Element: "video"}}}},
+		{Name: "not a pointer", Rules: []ValidationRule{{Field: "a", Required: true}}},
+		{Name: "checks nothing", Rules: []ValidationRule{{Field: "/a"}}},
+		{Name: "no element", Rules: []ValidationRule{{Body: &BodyRule{Min: 1}}}},
+		{Name: "max below min", Rules: []ValidationRule{{Body: &BodyRule{Element: "video", Min: 1, Max: &zero}}}},
+		{Name: "severity", Rules: []ValidationRule{{Field: "/a", Required: true, Severity: "fatal"}}},
+	}
+	vp := NewValidationProfiles()
+	for _, p := range invalid {
+		if err := vp.Add(ValidationProfile{Name: "valid", Rules: []ValidationRule{{Field: "/a", Required: true}}}, p); err == nil {
+			t.Errorf("Add of profile %q expected error", p.Name)
+		}
+	}
+	if got := len(vp.Profiles()); got != 1 {
+		t.Errorf("Add of invalid profiles left %d profiles, want the default one only", got)
+	}
+
+	if err := vp.Load([]byte(testProfiles)); err != nil {
+		t.Fatalf("Load unexpected error = %v", err)
+	}
+	if err := vp.Add(ValidationProfile{Name: "video", Rules: []ValidationRule{{Body: &BodyRule{Element: "video", Min: 2}}}}); err != nil {
+		t.Fatalf("Add unexpected error = %v", err)
+	}
+	var names []string
+	for _, p := range vp.Profiles() {
+		names = append(names, p.Name)
+	}
+	if want := []string{DefaultProfileName, "sponsored", "video", "svt"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("Profiles got = %v, want = %v", names, want)
+	}
+	if rules := vp.Profiles()[2].Rules; rules[0].Body.Min != 2 {
+		t.Errorf("Add did not replace the profile of the same name, got = %+v", rules)
+	}
+}
+
+func TestValidationProfilesValidate(t *testing.T) {
+	vp := NewValidationProfiles()
+	if err := vp.Load([]byte(testProfiles)); err != nil {
+		t.Fatalf("Load unexpected error = %v", err)
+	}
+	tests := []struct {
+		name string
+		data string
+		want []string
+	}{
+		{"no profile but default", `{"type": "article", "licensetype": "editorial", "sourceURL": "https://www.dn.se/a"}`, nil},
+		{"allowed license in other case", `{"type": "article", "licensetype": "Editorial", "sourceURL": "https://www.dn.se/a"}`, nil},
+		{"license not allowed", `{"type": "article", "licensetype": "paid", "sourceURL": "https://www.dn.se/a"}`,
+			[]string{"/licensetype " + CodeUnsupportedValue + " error"}},
+		{"sponsored", `{"type": "article", "licensetype": "sponsored", "licensetext": " ", "sourceURL": "https://www.dn.se/a"}`,
+			[]string{"/licensepromo " + CodeRequired + " warning", "/licensetext " + CodeRequired + " error"}},
+		{"video without video", `{"type": "video", "licensetype": "editorial", "body": [{"type": "paragraph"}]}`,
+			[]string{"/body " + CodeBodyConstraint + " error"}},
+		{"video in gallery", `{"type": "video", "licensetype": "editorial", "body": [{"type": "gallery", "body": [{"type": "video"}]}]}`, nil},
+		{"two videos", `{"type": "Video", "licensetype": "editorial", "body": [{"type": "video"}, {"type": "video"}]}`,
+			[]string{"/body " + CodeBodyConstraint + " error"}},
+		{"publisher", `{"type": "article", "licensetype": "editorial", "sourceURL": "https://WWW.svt.se/a", "mainimage": {"source": "a.jpg"}}`,
+			[]string{"/mainimage/caption " + CodeRequired + " error"}},
+	}
+	for _, tt := range tests {
+		var mt MitemTiniest
+		if err := json.Unmarshal([]byte(tt.data), &mt); err != nil {
+			t.Fatalf("%s: unable to unmarshal mitem, error = %v", tt.name, err)
+		}
+		var got []string
+		for _, err := range vp.Validate(&mt, json.RawMessage(tt.data)) {
+			ve := err.(*ValidationError)
+			got = append(got, ve.Path+" "+ve.Code+" "+string(ve.Severity))
+			if ve.Path == "/mainimage/caption" && ve.Message != "SVT images need captions" {
+				t.Errorf("%s: message got = %q", tt.name, ve.Message)
+			}
+		}
+		sort.Strings(got)
+		if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+			t.Errorf("%s: Validate got = %v, want = %v", tt.name, got, tt.want)
+		}
+	}
+}
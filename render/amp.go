@@ -0,0 +1,93 @@
+package render
+
+import (
+	"io"
+
+	"github.com/jedynykaban/testkeyholder/model"
+	"github.com/jedynykaban/testkeyholder/video"
+)
+
+// ampTemplates override the default templates with the AMP components.
+// amp-img, amp-carousel and the video players need the dimensions, thus images without them cannot be exported.
+const ampTemplates = `
+{{define "mitem"}}<!doctype html>
+<html amp lang="{{.Language}}">
+<head>
+<meta charset="utf-8">
+<title>{{.Headline}}</title>
+<link rel="canonical" href="{{.CanonicalURL}}">
+<meta name="viewport" content="width=device-width,minimum-scale=1,initial-scale=1">
+<script async src="https://cdn.ampproject.org/v0.js"></script>
+{{if .Types.gallery}}<script async custom-element="amp-carousel" src="https://cdn.ampproject.org/v0/amp-carousel-0.1.js"></script>
+{{end}}{{if .Types.youtube}}<script async custom-element="amp-youtube" src="https://cdn.ampproject.org/v0/amp-youtube-0.1.js"></script>
+{{end}}{{if .Types.vimeo}}<script async custom-element="amp-vimeo" src="https://cdn.ampproject.org/v0/amp-vimeo-0.1.js"></script>
+{{end}}{{if .Types.dailymotion}}<script async custom-element="amp-dailymotion" src="https://cdn.ampproject.org/v0/amp-dailymotion-0.1.js"></script>
+{{end}}{{if .Types.mp4}}<script async custom-element="amp-video" src="https://cdn.ampproject.org/v0/amp-video-0.1.js"></script>
+{{end}}{{if .Mitem.Meta.Analytics}}<script async custom-element="amp-analytics" src="https://cdn.ampproject.org/v0/amp-analytics-0.1.js"></script>
+{{end}}<style amp-boilerplate>body{-webkit-animation:-amp-start 8s steps(1,end) 0s 1 normal both;-moz-animation:-amp-start 8s steps(1,end) 0s 1 normal both;-ms-animation:-amp-start 8s steps(1,end) 0s 1 normal both;animation:-amp-start 8s steps(1,end) 0s 1 normal both}@-webkit-keyframes -amp-start{from{visibility:hidden}to{visibility:visible}}@-moz-keyframes -amp-start{from{visibility:hidden}to{visibility:visible}}@-ms-keyframes -amp-start{from{visibility:hidden}to{visibility:visible}}@-o-keyframes -amp-start{from{visibility:hidden}to{visibility:visible}}@keyframes -amp-start{from{visibility:hidden}to{visibility:visible}}</style><noscript><style amp-boilerplate>body{-webkit-animation:none;-moz-animation:none;-ms-animation:none;animation:none}</style></noscript>
+</head>
+<body>
+<article class="mitem">
+<h1 class="headline">{{.Headline}}</h1>
+{{if .Authors}}<p class="byline">{{join .Authors ", "}}</p>
+{{end}}{{with .MainImage}}{{template "image" .}}{{end}}{{.Body}}</article>
+{{range .Mitem.Meta.Analytics}}{{if eq .Type "ga"}}<amp-analytics type="googleanalytics"><script type="application/json">{{gaConfig .ID}}</script></amp-analytics>
+{{end}}{{end}}</body>
+</html>
+{{end}}
+
+{{define "image"}}<figure class="image"><amp-img src="{{.Source}}" width="{{.Width}}" height="{{.Height}}" layout="responsive" alt="{{.Alt}}"></amp-img>{{if .Caption}}<figcaption>{{.Caption}}</figcaption>{{end}}</figure>
+{{end}}
+
+{{define "video"}}{{if eq .Provider "youtube"}}<amp-youtube data-videoid="{{.ID}}" layout="responsive" width="480" height="270"></amp-youtube>
+{{else if eq .Provider "vimeo"}}<amp-vimeo data-videoid="{{.ID}}" layout="responsive" width="500" height="281"></amp-vimeo>
+{{else if eq .Provider "dailymotion"}}<amp-dailymotion data-videoid="{{.ID}}" layout="responsive" width="480" height="270"></amp-dailymotion>
+{{else if and (eq .Provider "mp4") (isHTTPS .EmbedURL)}}<amp-video src="{{.EmbedURL}}" layout="responsive" width="640" height="360"{{if .ThumbnailURL}} poster="{{.ThumbnailURL}}"{{end}} controls></amp-video>
+{{end}}{{end}}
+
+{{define "gallery"}}{{if .Images}}<amp-carousel type="slides" layout="responsive" width="{{.Width}}" height="{{.Height}}">
+{{range .Images}}<amp-img src="{{.Source}}" width="{{.Width}}" height="{{.Height}}" layout="responsive" alt="{{.Alt}}"></amp-img>
+{{end}}</amp-carousel>
+{{end}}{{end}}
+`
+
+// ampExporter exports mitems to AMP HTML
+type ampExporter struct {
+	r *Renderer
+}
+
+var _ Exporter = &ampExporter{}
+
+// NewAMPExporter - ctor like function - creates AMP exporter, the options customise its renderer.
+// Ads are not rendered unless an ad hook is given.
+func NewAMPExporter(opts ...Option) (Exporter, error) {
+	r, err := New(append([]Option{WithTemplates(ampTemplates), WithAdHook(nil)}, opts...)...)
+	if err != nil {
+		return nil, err
+	}
+	return &ampExporter{r: r}, nil
+}
+
+// Format implements Exporter
+func (ae *ampExporter) Format() string {
+	return FormatAMP
+}
+
+// Check implements Exporter. AMP pages have to link their canonical page
+// and all the images have to have their dimensions set. amp-video plays https files only.
+func (ae *ampExporter) Check(m *model.TheNewMitem) []error {
+	return checkMitem(m, constraints{
+		canonicalRequired: true,
+		imageSizeRequired: true,
+		gaSupported:       true,
+		videoProviders:    []string{video.ProviderYoutube, video.ProviderVimeo, video.ProviderDailymotion, video.ProviderMP4},
+		httpsFiles:        true,
+	})
+}
+
+// Export implements Exporter
+func (ae *ampExporter) Export(w io.Writer, m *model.TheNewMitem) error {
+	return export(ae, w, m, func() error {
+		return ae.r.RenderMitem(w, m)
+	})
+}
@@ -0,0 +1,193 @@
+package render
+
+import (
+	"encoding/json"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jedynykaban/testkeyholder/model"
+	"github.com/jedynykaban/testkeyholder/sanitize"
+	"github.com/jedynykaban/testkeyholder/video"
+)
+
+// appleNewsVersion is the version of Apple News Format the documents are made in
+const appleNewsVersion = "1.7"
+
+// anfDocument is the Apple News Format article document, the required properties only
+// along with the metadata we have
+type anfDocument struct {
+	Version             string                  `json:"version"`
+	Identifier          string                  `json:"identifier"`
+	Title               string                  `json:"title"`
+	Language            string                  `json:"language"`
+	Layout              anfLayout               `json:"layout"`
+	Components          []anfComponent          `json:"components"`
+	ComponentTextStyles map[string]anfTextStyle `json:"componentTextStyles"`
+	Metadata            anfMetadata             `json:"metadata"`
+}
+
+type anfLayout struct {
+	Columns int `json:"columns"`
+	Width   int `json:"width"`
+}
+
+type anfTextStyle struct {
+	FontName string `json:"fontName,omitempty"`
+}
+
+type anfComponent struct {
+	Role     string           `json:"role"`
+	Text     string           `json:"text,omitempty"`
+	Format   string           `json:"format,omitempty"`
+	URL      string           `json:"URL,omitempty"`
+	Caption  string           `json:"caption,omitempty"`
+	StillURL string           `json:"stillURL,omitempty"`
+	Items    []anfGalleryItem `json:"items,omitempty"`
+}
+
+type anfGalleryItem struct {
+	URL     string `json:"URL"`
+	Caption string `json:"caption,omitempty"`
+}
+
+type anfMetadata struct {
+	CanonicalURL  string   `json:"canonicalURL,omitempty"`
+	Authors       []string `json:"authors,omitempty"`
+	DatePublished string   `json:"datePublished,omitempty"`
+	ThumbnailURL  string   `json:"thumbnailURL,omitempty"`
+	Excerpt       string   `json:"excerpt,omitempty"`
+}
+
+// appleNewsRoles map the text elements to the component roles
+var appleNewsRoles = map[string]string{
+	model.ElementTypeParagraph: "body",
+	model.ElementTypeInfo:      "pullquote",
+	model.ElementTypeSubhead:   "intro",
+}
+
+// appleNewsExporter exports mitems to Apple News Format JSON
+type appleNewsExporter struct {
+	language string
+}
+
+var _ Exporter = &appleNewsExporter{}
+
+// NewAppleNewsExporter - ctor like function - creates Apple News exporter making documents in the language
+func NewAppleNewsExporter(language string) Exporter {
+	return &appleNewsExporter{language: language}
+}
+
+// Format implements Exporter
+func (ae *appleNewsExporter) Format() string {
+	return FormatAppleNews
+}
+
+// Check implements Exporter. Apple News documents have to have an identifier,
+// the mitem's ID or its slug is used. Analytics is not supported by the format,
+// video files have to be HLS streams.
+func (ae *appleNewsExporter) Check(m *model.TheNewMitem) []error {
+	ret := checkMitem(m, constraints{
+		videoProviders: []string{video.ProviderYoutube, video.ProviderVimeo, video.ProviderDailymotion, video.ProviderHLS},
+	})
+	if len(appleNewsIdentifier(m)) == 0 {
+		ret = append(ret, model.NewValidationError("/id", model.CodeRequired, nil, "Either ID or slug is required as the document identifier"))
+	}
+	if len(ae.language) == 0 {
+		ret = append(ret, model.NewValidationError("", model.CodeRequired, nil, "Document language is not set"))
+	}
+	return ret
+}
+
+// Export implements Exporter
+func (ae *appleNewsExporter) Export(w io.Writer, m *model.TheNewMitem) error {
+	return export(ae, w, m, func() error {
+		doc, err := ae.document(m)
+		if err != nil {
+			return err
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(doc)
+	})
+}
+
+func (ae *appleNewsExporter) document(m *model.TheNewMitem) (*anfDocument, error) {
+	elements, err := m.Body.Elements()
+	if err != nil {
+		return nil, err
+	}
+	doc := &anfDocument{
+		Version:             appleNewsVersion,
+		Identifier:          appleNewsIdentifier(m),
+		Title:               sanitize.Text(m.Headline),
+		Language:            ae.language,
+		Layout:              anfLayout{Columns: 7, Width: 1024},
+		ComponentTextStyles: map[string]anfTextStyle{"default": {}},
+		Metadata: anfMetadata{
+			ThumbnailURL: m.MainImage.Source,
+			Excerpt:      m.Meta.Excerpt,
+		},
+	}
+	doc.Metadata.CanonicalURL, _ = CanonicalLink(m)
+	if !m.CreationDate.IsZero() {
+		doc.Metadata.DatePublished = m.CreationDate.UTC().Format(time.RFC3339)
+	}
+	doc.Components = append(doc.Components, anfComponent{Role: "title", Text: doc.Title})
+	if len(m.Meta.Authors) > 0 {
+		for _, author := range m.Meta.Authors {
+			doc.Metadata.Authors = append(doc.Metadata.Authors, author.Name)
+		}
+		doc.Components = append(doc.Components, anfComponent{Role: "byline", Text: strings.Join(doc.Metadata.Authors, ", ")})
+	}
+	if len(m.MainImage.Source) > 0 {
+		doc.Components = append(doc.Components, anfComponent{Role: "photo", URL: m.MainImage.Source, Caption: sanitize.Text(m.MainImage.Caption)})
+	}
+	for _, element := range elements {
+		if component, ok := appleNewsComponent(element); ok {
+			doc.Components = append(doc.Components, component)
+		}
+	}
+	return doc, nil
+}
+
+// appleNewsComponent converts the body element, the elements the format cannot hold are skipped
+func appleNewsComponent(element model.BodyElement) (anfComponent, bool) {
+	switch e := element.(type) {
+	case *model.HeadingElement:
+		return anfComponent{Role: "heading" + strconv.Itoa(e.Level), Text: sanitize.Text(e.Content)}, true
+	case model.TextElement:
+		clean, _ := sanitize.Sanitize(e.Text())
+		return anfComponent{Role: appleNewsRoles[e.ElementType()], Text: clean, Format: "html"}, true
+	case *model.ImageElement:
+		return anfComponent{Role: "photo", URL: e.Source, Caption: sanitize.Text(e.Caption)}, true
+	case *model.VideoElement:
+		v, err := video.Parse(e.VideoType, e.Source)
+		switch {
+		case err != nil, v.Provider == video.ProviderMP4, v.Provider == video.ProviderJWPlayer:
+		case v.Provider == video.ProviderHLS:
+			return anfComponent{Role: "video", URL: v.EmbedURL(), StillURL: v.ThumbnailURL()}, true
+		default:
+			return anfComponent{Role: "embedwebvideo", URL: v.EmbedURL()}, true
+		}
+	case *model.GalleryElement:
+		var items []anfGalleryItem
+		for _, child := range e.Body {
+			if image, ok := child.(*model.ImageElement); ok {
+				items = append(items, anfGalleryItem{URL: image.Source, Caption: sanitize.Text(image.Caption)})
+			}
+		}
+		if len(items) > 0 {
+			return anfComponent{Role: "gallery", Items: items}, true
+		}
+	}
+	return anfComponent{}, false
+}
+
+func appleNewsIdentifier(m *model.TheNewMitem) string {
+	if len(m.ID) > 0 {
+		return m.ID
+	}
+	return m.Slug
+}
@@ -0,0 +1,243 @@
+package render
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"net/url"
+	"strings"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+
+	"github.com/jedynykaban/testkeyholder/canonical"
+	"github.com/jedynykaban/testkeyholder/model"
+	"github.com/jedynykaban/testkeyholder/video"
+)
+
+// Syndication formats the mitems can be exported to
+const (
+	FormatAMP             = "amp"
+	FormatAppleNews       = "applenews"
+	FormatInstantArticles = "instantarticles"
+)
+
+// DefaultLanguage is the language the mitems are exported in unless set otherwise
+const DefaultLanguage = "sv"
+
+// analyticsTypeGA is the Analytics.Type of Google Analytics
+const analyticsTypeGA = "ga"
+
+// Exporter turns the mitem into one of the syndication formats
+type Exporter interface {
+	// Format returns the name of the format i.e. amp
+	Format() string
+	// Check reports what in the mitem does not meet the format's constraints.
+	// All returned errors are of *model.ValidationError type pointing into TheNewMitem,
+	// warnings describe the parts of the mitem left out of the export.
+	Check(m *model.TheNewMitem) []error
+	// Export writes the mitem in the format, mitems failing Check with errors are not exported
+	Export(w io.Writer, m *model.TheNewMitem) error
+}
+
+// ExportError is returned by Export when the mitem does not meet the format's constraints
+type ExportError struct {
+	Format string
+	Errors []error
+}
+
+// Error implements error
+func (e *ExportError) Error() string {
+	msgs := make([]string, 0, len(e.Errors))
+	for _, err := range e.Errors {
+		msgs = append(msgs, err.Error())
+	}
+	return fmt.Sprintf("Mitem cannot be exported to %s: %s", e.Format, strings.Join(msgs, "; "))
+}
+
+// NewExporter - ctor like function - creates the exporter of the format making documents in the language,
+// the options customise the renderer of the HTML based formats
+func NewExporter(format string, language string, opts ...Option) (Exporter, error) {
+	opts = append([]Option{WithLanguage(language)}, opts...)
+	switch format {
+	case FormatAMP:
+		return NewAMPExporter(opts...)
+	case FormatAppleNews:
+		return NewAppleNewsExporter(language), nil
+	case FormatInstantArticles:
+		return NewInstantArticlesExporter(opts...)
+	}
+	return nil, fmt.Errorf("Unknown export format %s, want one of: %s", format, strings.Join(Formats(), ", "))
+}
+
+// Formats returns the names of the supported formats
+func Formats() []string {
+	return []string{FormatAMP, FormatAppleNews, FormatInstantArticles}
+}
+
+// CanonicalLink makes the canonical URL of the mitem out of its sourceURL.
+// Mitems created inside mosaiq link to the MosaiqPrimary.Domain,
+// or to the publisher's primary domain (see canonical.Canonicalizer.PrimaryDomain) when it is not set.
+func CanonicalLink(m *model.TheNewMitem) (string, error) {
+	var domain string
+	if m.Meta.MosaiqPrimary.Set {
+		domain = m.Meta.MosaiqPrimary.Domain
+		if len(domain) == 0 {
+			domain = canonical.Default.PrimaryDomain(m.Meta.SourceURL)
+		}
+	}
+	return canonical.Canonicalize(m.Meta.SourceURL, domain)
+}
+
+// export checks the mitem and writes it unless there are errors, warnings are logged
+func export(e Exporter, w io.Writer, m *model.TheNewMitem, write func() error) error {
+	errs := e.Check(m)
+	if model.HasErrors(errs) {
+		var ret []error
+		for _, err := range errs {
+			if model.AsValidationError(err).Severity == model.SeverityError {
+				ret = append(ret, err)
+			}
+		}
+		return &ExportError{Format: e.Format(), Errors: ret}
+	}
+	for _, warning := range errs {
+		log.WithFields(log.Fields{"format": e.Format(), "warning": warning}).Warn("Mitem exported partially")
+	}
+	return write()
+}
+
+// constraints are the rules checkMitem enforces, they differ per format
+type constraints struct {
+	canonicalRequired bool
+	imageSizeRequired bool
+	gaSupported       bool
+	// videoProviders the format can play, other videos are left out
+	videoProviders []string
+	// httpsFiles requires the video files to be served over https
+	httpsFiles bool
+}
+
+// checker collects the problems found in the mitem
+type checker struct {
+	c    constraints
+	errs []error
+}
+
+func (ch *checker) report(severity model.Severity, path, code string, value interface{}, msg string) {
+	e := model.NewValidationError(path, code, value, msg)
+	e.Severity = severity
+	ch.errs = append(ch.errs, e)
+}
+
+// checkMitem checks the parts of the mitem all the formats share
+func checkMitem(m *model.TheNewMitem, c constraints) []error {
+	ch := &checker{c: c}
+	if len(strings.TrimSpace(m.Headline)) == 0 {
+		ch.report(model.SeverityError, "/headline", model.CodeRequired, nil, "Headline is required")
+	}
+	if _, err := CanonicalLink(m); err != nil {
+		severity := model.SeverityWarning
+		if c.canonicalRequired {
+			severity = model.SeverityError
+		}
+		ch.report(severity, "/meta/sourceURL", model.CodeInvalidFormat, m.Meta.SourceURL, "Canonical link cannot be made: "+err.Error())
+	}
+	if len(m.MainImage.Source) > 0 {
+		ch.image("/mainImage", m.MainImage.Source, m.MainImage.Width, m.MainImage.Height)
+	}
+	for idx, analytics := range m.Meta.Analytics {
+		if analytics.Type != analyticsTypeGA || !c.gaSupported {
+			ch.report(model.SeverityWarning, model.JSONPointer("/meta/analytics", idx), model.CodeUnsupportedValue, analytics.Type,
+				"Analytics of type "+analytics.Type+" is not exported")
+		}
+	}
+	elements, err := m.Body.Elements()
+	if err != nil {
+		ch.report(model.SeverityError, "/body", model.CodeMalformed, nil, err.Error())
+		return ch.errs
+	}
+	ch.body(elements, "/body", false)
+	return ch.errs
+}
+
+func (ch *checker) body(elements []model.BodyElement, path string, inGallery bool) {
+	for idx, element := range elements {
+		elementPath := model.JSONPointer(path, idx)
+		switch e := element.(type) {
+		case *model.ImageElement:
+			ch.image(elementPath, e.Source, e.Width, e.Height)
+		case *model.VideoElement:
+			ch.video(elementPath, e)
+		case *model.GalleryElement:
+			var images int
+			for _, child := range e.Body {
+				if _, ok := child.(*model.ImageElement); ok {
+					images++
+				}
+			}
+			if images == 0 {
+				ch.report(model.SeverityWarning, elementPath, model.CodeEmpty, nil, "Gallery without images is not exported")
+			}
+			ch.body(e.Body, model.JSONPointer(elementPath, "body"), true)
+		case *model.UnknownElement:
+			ch.report(model.SeverityWarning, elementPath, model.CodeUnsupportedValue, e.Type, "Element of type "+e.Type+" is not exported")
+		default:
+			if inGallery {
+				ch.report(model.SeverityWarning, elementPath, model.CodeUnsupportedValue, element.ElementType(),
+					"Only images of the gallery are exported")
+			}
+		}
+	}
+}
+
+func (ch *checker) video(path string, e *model.VideoElement) {
+	v, err := video.Parse(e.VideoType, e.Source)
+	switch {
+	case err != nil:
+		ch.report(model.SeverityWarning, path, model.CodeUnsupportedValue, e.Source, "Video is not exported: "+err.Error())
+	case !contains(ch.c.videoProviders, v.Provider):
+		ch.report(model.SeverityWarning, path, model.CodeUnsupportedValue, e.VideoType, "Video of type "+v.Provider+" is not exported")
+	case v.IsFile() && ch.c.httpsFiles && !strings.HasPrefix(v.ID, "https://"):
+		ch.report(model.SeverityWarning, model.JSONPointer(path, "source"), model.CodeInvalidFormat, e.Source, "Video file is not served over https, it is not exported")
+	}
+}
+
+func (ch *checker) image(path, source string, width, height int) {
+	u, err := url.Parse(source)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || len(u.Host) == 0 {
+		ch.report(model.SeverityError, model.JSONPointer(path, "source"), model.CodeInvalidFormat, source, "Image source has to be absolute http(s) URL")
+	}
+	if ch.c.imageSizeRequired && (width <= 0 || height <= 0) {
+		ch.report(model.SeverityError, path, model.CodeRequired, nil, "Image width and height are required")
+	}
+}
+
+// funcs are available to all the templates
+var funcs = template.FuncMap{
+	"join": strings.Join,
+	"isHTTPS": func(u string) bool {
+		return strings.HasPrefix(u, "https://")
+	},
+	"isoDate": func(t time.Time) string {
+		return t.UTC().Format(time.RFC3339)
+	},
+	// gaConfig makes the Google Analytics config of the amp-analytics element
+	"gaConfig": func(id string) map[string]interface{} {
+		return map[string]interface{}{
+			"vars": map[string]string{"account": id},
+			"triggers": map[string]interface{}{
+				"trackPageview": map[string]string{"on": "visible", "request": "pageview"},
+			},
+		}
+	},
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,168 @@
+package render
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jedynykaban/testkeyholder/canonical"
+	"github.com/jedynykaban/testkeyholder/model"
+)
+
+// exportable makes the mitem all the formats can export, change modifies it
+func exportable(change func(m *model.TheNewMitem)) *model.TheNewMitem {
+	m := &model.TheNewMitem{
+		ID:           "abc",
+		Headline:     "Storm i Norrland",
+		MainImage:    model.Image{Source: "https://img.svt.se/a.jpg", Width: 1280, Height: 720},
+		CreationDate: time.Date(2020, 1, 2, 9, 0, 0, 0, time.UTC),
+		Body: model.Body{
+			json.RawMessage(`{"type":"paragraph","content":"Hej <b>du</b>"}`),
+			json.RawMessage(`{"type":"video","source":"dQw4w9WgXcQ","videoType":"youtube"}`),
+		},
+		Meta: model.Meta{
+			SourceURL: "https://www.svt.se/nyheter/a?utm_source=fb",
+			Authors:   []model.Author{{Name: "Anna Svensson"}},
+			Analytics: []model.Analytics{{Type: "ga", ID: "UA-1"}},
+		},
+	}
+	if change != nil {
+		change(m)
+	}
+	return m
+}
+
+func TestExportersCheck(t *testing.T) {
+	tests := []struct {
+		name   string
+		mitem  *model.TheNewMitem
+		errors map[string]bool
+	}{
+		{"exportable", exportable(nil), nil},
+		{"missing headline", exportable(func(m *model.TheNewMitem) { m.Headline = " " }),
+			map[string]bool{FormatAMP: true, FormatAppleNews: true, FormatInstantArticles: true}},
+		{"image without size", exportable(func(m *model.TheNewMitem) { m.MainImage.Width = 0 }),
+			map[string]bool{FormatAMP: true}},
+		{"relative image", exportable(func(m *model.TheNewMitem) { m.MainImage.Source = "/a.jpg" }),
+			map[string]bool{FormatAMP: true, FormatAppleNews: true, FormatInstantArticles: true}},
+		{"invalid sourceURL", exportable(func(m *model.TheNewMitem) { m.Meta.SourceURL = "not a url" }),
+			map[string]bool{FormatAMP: true, FormatInstantArticles: true}},
+		{"no identifier", exportable(func(m *model.TheNewMitem) { m.ID = "" }),
+			map[string]bool{FormatAppleNews: true}},
+		{"unsupported parts", exportable(func(m *model.TheNewMitem) {
+			m.Body = append(m.Body,
+				json.RawMessage(`{"type":"video","source":"http://cdn.example.com/a.mp4","videoType":"mp4"}`),
+				json.RawMessage(`{"type":"gallery","body":[{"type":"paragraph","content":"x"}]}`),
+				json.RawMessage(`{"type":"table"}`))
+			m.Meta.Analytics = append(m.Meta.Analytics, model.Analytics{Type: "comscore"})
+		}), nil},
+	}
+	for _, tt := range tests {
+		for _, format := range Formats() {
+			e, err := NewExporter(format, DefaultLanguage)
+			if err != nil {
+				t.Fatalf("NewExporter(%s) unexpected error = %v", format, err)
+			}
+			errs := e.Check(tt.mitem)
+			if got := model.HasErrors(errs); got != tt.errors[format] {
+				t.Errorf("%s: %s Check errors = %v, want errors = %t", tt.name, format, errs, tt.errors[format])
+			}
+			var out bytes.Buffer
+			err = e.Export(&out, tt.mitem)
+			if _, ok := err.(*ExportError); ok != tt.errors[format] {
+				t.Errorf("%s: %s Export error = %v, want ExportError = %t", tt.name, format, err, tt.errors[format])
+			}
+			if err == nil && out.Len() == 0 {
+				t.Errorf("%s: %s Export wrote nothing", tt.name, format)
+			}
+		}
+	}
+}
+
+func TestExportDocuments(t *testing.T) {
+	m := exportable(nil)
+	tests := []struct {
+		format string
+		want   []string
+	}{
+		{FormatAMP, []string{
+			`<html amp lang="sv">`,
+			`<link rel="canonical" href="https://www.svt.se/nyheter/a">`,
+			`custom-element="amp-youtube"`,
+			`<amp-youtube data-videoid="dQw4w9WgXcQ"`,
+			`<amp-img src="https://img.svt.se/a.jpg" width="1280" height="720"`,
+			`<amp-analytics type="googleanalytics">`,
+		}},
+		{FormatInstantArticles, []string{
+			`<link rel="canonical" href="https://www.svt.se/nyheter/a">`,
+			`<time class="op-published" datetime="2020-01-02T09:00:00Z">`,
+			`<address>Anna Svensson</address>`,
+			`<iframe src="https://www.youtube.com/embed/dQw4w9WgXcQ"`,
+		}},
+		{FormatAppleNews, []string{
+			`"identifier": "abc"`,
+			`"canonicalURL": "https://www.svt.se/nyheter/a"`,
+			`"role": "embedwebvideo"`,
+			`"role": "body"`,
+		}},
+	}
+	for _, tt := range tests {
+		e, _ := NewExporter(tt.format, DefaultLanguage)
+		var out bytes.Buffer
+		if err := e.Export(&out, m); err != nil {
+			t.Fatalf("%s: Export unexpected error = %v", tt.format, err)
+		}
+		for _, want := range tt.want {
+			if !strings.Contains(out.String(), want) {
+				t.Errorf("%s: Export got:\n%s\nwant it to contain %s", tt.format, out.String(), want)
+			}
+		}
+	}
+
+	var doc anfDocument
+	e := NewAppleNewsExporter("en")
+	var out bytes.Buffer
+	if err := e.Export(&out, m); err != nil {
+		t.Fatalf("Export unexpected error = %v", err)
+	}
+	if err := json.Unmarshal(out.Bytes(), &doc); err != nil {
+		t.Fatalf("Unable to unmarshal Apple News document, error = %v", err)
+	}
+	if doc.Language != "en" || doc.Version != appleNewsVersion || len(doc.Components) != 5 {
+		t.Errorf("Apple News document got = %+v", doc)
+	}
+
+	if _, err := NewExporter("rss", DefaultLanguage); err == nil {
+		t.Errorf("NewExporter of unknown format expected error")
+	}
+}
+
+func TestCanonicalLink(t *testing.T) {
+	canonical.Default.SetPrimaryDomain("mosaiq.example.com", "www.example.com")
+	defer canonical.Default.SetPrimaryDomain("mosaiq.example.com", "")
+
+	tests := []struct {
+		name    string
+		meta    model.Meta
+		want    string
+		wantErr bool
+	}{
+		{"source", model.Meta{SourceURL: "https://www.svt.se/nyheter/a/?utm_source=fb#top"}, "https://www.svt.se/nyheter/a", false},
+		{"not mosaiq", model.Meta{SourceURL: "https://mosaiq.example.com/?p=1"}, "https://mosaiq.example.com/?p=1", false},
+		{"mosaiq domain", model.Meta{SourceURL: "https://mosaiq.example.com/?p=1", MosaiqPrimary: model.MosaiqPrimary{Set: true, Domain: "news.example.com"}}, "https://news.example.com/?p=1", false},
+		{"mosaiq registry", model.Meta{SourceURL: "https://mosaiq.example.com/?p=1", MosaiqPrimary: model.MosaiqPrimary{Set: true}}, "https://www.example.com/?p=1", false},
+		{"invalid", model.Meta{SourceURL: "not a url"}, "", true},
+	}
+	for _, tt := range tests {
+		got, err := CanonicalLink(&model.TheNewMitem{Meta: tt.meta})
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: CanonicalLink error = %v, wantErr = %t", tt.name, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s: CanonicalLink got = %q, want = %q", tt.name, got, tt.want)
+		}
+	}
+}
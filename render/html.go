@@ -0,0 +1,305 @@
+// Package render renders mitems to HTML the way the apps display them.
+package render
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"io"
+
+	"github.com/jedynykaban/testkeyholder/model"
+	"github.com/jedynykaban/testkeyholder/sanitize"
+	"github.com/jedynykaban/testkeyholder/video"
+)
+
+// DefaultAdInterval is the number of paragraphs between the ads placed by the default ad hook
+const DefaultAdInterval = 3
+
+// TextData is passed to the paragraph, heading, info and subhead templates.
+// Content is sanitised, see sanitize.Sanitize.
+type TextData struct {
+	Type    string
+	Level   int
+	Content template.HTML
+}
+
+// ImageData is passed to the image template, it is used for the main image as well
+type ImageData struct {
+	Source  string
+	Caption template.HTML
+	// Alt is the plain text of the caption
+	Alt    string
+	Width  int
+	Height int
+}
+
+// VideoData is passed to the video template, the fields but Source and VideoType
+// are empty for the videos that cannot be read (see video.Parse).
+// File is set for mp4 and hls videos, their EmbedURL is the URL of the file.
+type VideoData struct {
+	Source       string
+	VideoType    string
+	Provider     string
+	ID           string
+	EmbedURL     string
+	ThumbnailURL string
+	File         bool
+}
+
+// GalleryData is passed to the gallery template, the items are rendered already.
+// Images hold the gallery's images only, Width and Height are the largest of their dimensions.
+type GalleryData struct {
+	Items  []template.HTML
+	Images []*ImageData
+	Width  int
+	Height int
+}
+
+// UnknownData is passed to the unknown template, it renders nothing by default
+type UnknownData struct {
+	Type string
+}
+
+// AdData is passed to the ad template, Slot counts the ads from 1
+type AdData struct {
+	Slot int
+}
+
+// MitemData is passed to the mitem template.
+// CanonicalURL is empty when the mitem's sourceURL cannot be canonicalised, see CanonicalLink.
+// Types tells which element types the body holds, gallery children included,
+// video providers (youtube, mp4, ...) are recorded as well.
+type MitemData struct {
+	Headline     string
+	MainImage    *ImageData
+	Body         template.HTML
+	CanonicalURL string
+	Authors      []string
+	Language     string
+	Types        map[string]bool
+	Mitem        *model.TheNewMitem
+}
+
+// AdPosition describes the place after a top level body element where an ad can go
+type AdPosition struct {
+	// Index of the element the ad would follow
+	Index   int
+	Element model.BodyElement
+	// Paragraphs is the number of top level paragraphs up to the element, the element included
+	Paragraphs int
+	// Inserted is the number of ads inserted so far
+	Inserted int
+	// Remaining is the number of elements following the element
+	Remaining int
+}
+
+// AdHook tells whether an ad follows the element. It is not called once AdsPolicy.MaxAds is reached
+// or when the ads are off.
+type AdHook func(pos AdPosition) bool
+
+// EveryNParagraphs places an ad after every n-th paragraph, never at the very end of the body
+func EveryNParagraphs(n int) AdHook {
+	return func(pos AdPosition) bool {
+		_, ok := pos.Element.(*model.ParagraphElement)
+		return ok && pos.Remaining > 0 && n > 0 && pos.Paragraphs%n == 0
+	}
+}
+
+// Renderer renders mitems to HTML
+type Renderer struct {
+	tmpl     *template.Template
+	adHook   AdHook
+	language string
+}
+
+// Option allows one to customise the Renderer created by New
+type Option func(r *Renderer) error
+
+// WithTemplates parses the templates overriding the default ones of the same name
+func WithTemplates(text string) Option {
+	return func(r *Renderer) error {
+		_, err := r.tmpl.Parse(text)
+		return err
+	}
+}
+
+// WithAdHook sets the hook placing the ads, EveryNParagraphs(DefaultAdInterval) is used by default
+func WithAdHook(hook AdHook) Option {
+	return func(r *Renderer) error {
+		r.adHook = hook
+		return nil
+	}
+}
+
+// WithLanguage sets the language of the rendered documents, DefaultLanguage is used by default
+func WithLanguage(language string) Option {
+	return func(r *Renderer) error {
+		r.language = language
+		return nil
+	}
+}
+
+// New - ctor like function - creates a renderer with the default templates
+func New(opts ...Option) (*Renderer, error) {
+	return newRenderer(defaultTemplates, opts...)
+}
+
+// newRenderer creates a renderer with the base templates, exporters use it with their own ones
+func newRenderer(base string, opts ...Option) (*Renderer, error) {
+	r := &Renderer{
+		tmpl:     template.Must(template.New("render").Funcs(funcs).Parse(base)),
+		adHook:   EveryNParagraphs(DefaultAdInterval),
+		language: DefaultLanguage,
+	}
+	for _, opt := range opts {
+		if err := opt(r); err != nil {
+			return nil, fmt.Errorf("Unable to set up renderer, error = %s", err.Error())
+		}
+	}
+	return r, nil
+}
+
+// RenderMitem renders the headline, the main image and the body with the ads allowed by the mitem's policy
+func (r *Renderer) RenderMitem(w io.Writer, m *model.TheNewMitem) error {
+	elements, err := m.Body.Elements()
+	if err != nil {
+		return err
+	}
+	var body bytes.Buffer
+	if err := r.RenderElements(&body, elements, m.Meta.AdsPolicy); err != nil {
+		return err
+	}
+	data := MitemData{
+		Headline: m.Headline,
+		Body:     template.HTML(body.String()),
+		Language: r.language,
+		Types:    make(map[string]bool),
+		Mitem:    m,
+	}
+	model.WalkBody(elements, func(path string, element model.BodyElement) {
+		data.Types[element.ElementType()] = true
+		if e, ok := element.(*model.VideoElement); ok {
+			if v, err := video.Parse(e.VideoType, e.Source); err == nil {
+				data.Types[v.Provider] = true
+			}
+		}
+	})
+	data.CanonicalURL, _ = CanonicalLink(m)
+	for _, author := range m.Meta.Authors {
+		data.Authors = append(data.Authors, author.Name)
+	}
+	if len(m.MainImage.Source) > 0 {
+		data.MainImage = imageData(m.MainImage.Source, m.MainImage.Caption, m.MainImage.Width, m.MainImage.Height)
+	}
+	return r.tmpl.ExecuteTemplate(w, "mitem", data)
+}
+
+// RenderBody renders the raw body with the ads allowed by the policy
+func (r *Renderer) RenderBody(w io.Writer, body model.Body, ads model.AdsPolicy) error {
+	elements, err := body.Elements()
+	if err != nil {
+		return err
+	}
+	return r.RenderElements(w, elements, ads)
+}
+
+// RenderElements renders the body elements with the ads allowed by the policy
+func (r *Renderer) RenderElements(w io.Writer, elements []model.BodyElement, ads model.AdsPolicy) error {
+	var paragraphs, inserted int
+	for idx, element := range elements {
+		if err := r.renderElement(w, element); err != nil {
+			return err
+		}
+		if _, ok := element.(*model.ParagraphElement); ok {
+			paragraphs++
+		}
+		if !ads.On || inserted >= ads.MaxAds || r.adHook == nil {
+			continue
+		}
+		pos := AdPosition{Index: idx, Element: element, Paragraphs: paragraphs, Inserted: inserted, Remaining: len(elements) - idx - 1}
+		if r.adHook(pos) {
+			inserted++
+			if err := r.tmpl.ExecuteTemplate(w, "ad", AdData{Slot: inserted}); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (r *Renderer) renderElement(w io.Writer, element model.BodyElement) error {
+	switch e := element.(type) {
+	case *model.ParagraphElement:
+		return r.tmpl.ExecuteTemplate(w, "paragraph", textData(e))
+	case *model.HeadingElement:
+		return r.tmpl.ExecuteTemplate(w, "heading", textData(e))
+	case *model.InfoElement:
+		return r.tmpl.ExecuteTemplate(w, "info", textData(e))
+	case *model.SubheadElement:
+		return r.tmpl.ExecuteTemplate(w, "subhead", textData(e))
+	case *model.ImageElement:
+		return r.tmpl.ExecuteTemplate(w, "image", imageData(e.Source, e.Caption, e.Width, e.Height))
+	case *model.VideoElement:
+		return r.tmpl.ExecuteTemplate(w, "video", videoData(e))
+	case *model.GalleryElement:
+		var data GalleryData
+		for _, child := range e.Body {
+			if image, ok := child.(*model.ImageElement); ok {
+				data.Images = append(data.Images, imageData(image.Source, image.Caption, image.Width, image.Height))
+				data.Width = max(data.Width, image.Width)
+				data.Height = max(data.Height, image.Height)
+			}
+			var item bytes.Buffer
+			if err := r.renderElement(&item, child); err != nil {
+				return err
+			}
+			data.Items = append(data.Items, template.HTML(item.String()))
+		}
+		return r.tmpl.ExecuteTemplate(w, "gallery", data)
+	default:
+		return r.tmpl.ExecuteTemplate(w, "unknown", UnknownData{Type: element.ElementType()})
+	}
+}
+
+func textData(e model.TextElement) TextData {
+	ret := TextData{Type: e.ElementType(), Content: safeHTML(e.Text())}
+	if heading, ok := e.(*model.HeadingElement); ok {
+		ret.Level = heading.Level
+	}
+	return ret
+}
+
+func imageData(source, caption string, width, height int) *ImageData {
+	return &ImageData{
+		Source:  source,
+		Caption: safeHTML(caption),
+		Alt:     sanitize.Text(caption),
+		Width:   width,
+		Height:  height,
+	}
+}
+
+// safeHTML sanitises the content, so it can be trusted by the templates
+func safeHTML(content string) template.HTML {
+	clean, _ := sanitize.Sanitize(content)
+	return template.HTML(clean)
+}
+
+func videoData(e *model.VideoElement) VideoData {
+	ret := VideoData{Source: e.Source, VideoType: e.VideoType}
+	if v, err := video.Parse(e.VideoType, e.Source); err == nil {
+		ret.Provider = v.Provider
+		ret.ID = v.ID
+		ret.EmbedURL = v.EmbedURL()
+		ret.ThumbnailURL = v.ThumbnailURL()
+		ret.File = v.IsFile()
+	}
+	return ret
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,115 @@
+package render
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/jedynykaban/testkeyholder/model"
+)
+
+// paragraphs makes the body of n paragraphs
+func paragraphs(n int) model.Body {
+	var ret model.Body
+	for i := 1; i <= n; i++ {
+		ret = append(ret, json.RawMessage(fmt.Sprintf(`{"type":"paragraph","content":"p%d"}`, i)))
+	}
+	return ret
+}
+
+func TestRenderMitem(t *testing.T) {
+	m := &model.TheNewMitem{
+		Headline:  "Storm <i>i</i> Norrland",
+		MainImage: model.Image{Source: "https://img.svt.se/a.jpg", Caption: "Foto: <b>TT</b>", Width: 1280, Height: 720},
+		Body: model.Body{
+			json.RawMessage(`{"type":"paragraph","content":"Hej <b>du</b><script>alert(1)</script>"}`),
+			json.RawMessage(`{"type":"h2","content":"Rubrik"}`),
+			json.RawMessage(`{"type":"video","source":"dQw4w9WgXcQ","videoType":"youtube"}`),
+			json.RawMessage(`{"type":"gallery","body":[{"type":"image","source":"https://img.svt.se/b.jpg","caption":"b"}]}`),
+			json.RawMessage(`{"type":"table","rows":[]}`),
+		},
+	}
+	r, err := New()
+	if err != nil {
+		t.Fatalf("New unexpected error = %v", err)
+	}
+	var out bytes.Buffer
+	if err := r.RenderMitem(&out, m); err != nil {
+		t.Fatalf("RenderMitem unexpected error = %v", err)
+	}
+	got := out.String()
+	for _, want := range []string{
+		`<h1 class="headline">Storm &lt;i&gt;i&lt;/i&gt; Norrland</h1>`,
+		`<img src="https://img.svt.se/a.jpg" width="1280" height="720" alt="Foto: TT"><figcaption>Foto: <b>TT</b></figcaption>`,
+		`<p>Hej <b>du</b></p>`,
+		`<h2>Rubrik</h2>`,
+		`<iframe src="https://www.youtube.com/embed/dQw4w9WgXcQ"`,
+		`<div class="gallery">`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("RenderMitem got:\n%s\nwant it to contain %s", got, want)
+		}
+	}
+	if strings.Contains(got, "script") || strings.Contains(got, "table") || strings.Contains(got, `class="ad"`) {
+		t.Errorf("RenderMitem got:\n%s", got)
+	}
+}
+
+func TestRenderElementsAds(t *testing.T) {
+	tests := []struct {
+		name       string
+		paragraphs int
+		ads        model.AdsPolicy
+		hook       AdHook
+		want       int
+	}{
+		{"ads off", 7, model.AdsPolicy{On: false, MaxAds: 5}, EveryNParagraphs(3), 0},
+		{"every third", 7, model.AdsPolicy{On: true, MaxAds: 5}, EveryNParagraphs(3), 2},
+		{"never at the end", 6, model.AdsPolicy{On: true, MaxAds: 5}, EveryNParagraphs(3), 1},
+		{"max ads", 20, model.AdsPolicy{On: true, MaxAds: 2}, EveryNParagraphs(3), 2},
+		{"no hook", 7, model.AdsPolicy{On: true, MaxAds: 5}, nil, 0},
+		{"custom hook", 4, model.AdsPolicy{On: true, MaxAds: 5}, func(pos AdPosition) bool { return pos.Index == 0 }, 1},
+	}
+	for _, tt := range tests {
+		r, err := New(WithAdHook(tt.hook))
+		if err != nil {
+			t.Fatalf("%s: New unexpected error = %v", tt.name, err)
+		}
+		var out bytes.Buffer
+		if err := r.RenderBody(&out, paragraphs(tt.paragraphs), tt.ads); err != nil {
+			t.Fatalf("%s: RenderBody unexpected error = %v", tt.name, err)
+		}
+		if got := strings.Count(out.String(), `class="ad"`); got != tt.want {
+			t.Errorf("%s: RenderBody ads got = %d, want = %d\n%s", tt.name, got, tt.want, out.String())
+		}
+	}
+
+	r, _ := New()
+	var out bytes.Buffer
+	if err := r.RenderBody(&out, paragraphs(7), model.AdsPolicy{On: true, MaxAds: 5}); err != nil {
+		t.Fatalf("RenderBody unexpected error = %v", err)
+	}
+	want := "<p>p3</p>\n<div class=\"ad\" data-slot=\"1\"></div>\n<p>p4</p>"
+	if !strings.Contains(out.String(), want) {
+		t.Errorf("RenderBody got:\n%s\nwant the first ad after the third paragraph", out.String())
+	}
+}
+
+func TestWithTemplates(t *testing.T) {
+	r, err := New(WithTemplates(`{{define "paragraph"}}<div class="text">{{.Content}}</div>{{end}}`))
+	if err != nil {
+		t.Fatalf("New unexpected error = %v", err)
+	}
+	var out bytes.Buffer
+	if err := r.RenderBody(&out, paragraphs(1), model.AdsPolicy{}); err != nil {
+		t.Fatalf("RenderBody unexpected error = %v", err)
+	}
+	if got := out.String(); got != `<div class="text">p1</div>` {
+		t.Errorf("RenderBody with overridden template got = %q", got)
+	}
+	if _, err := New(WithTemplates(`{{define "paragraph"}}{{.Content}`)); err == nil {
+		t.Errorf("New with broken template expected error")
+	}
+}
@@ -0,0 +1,97 @@
+package render
+
+import (
+	"io"
+
+	"github.com/jedynykaban/testkeyholder/model"
+	"github.com/jedynykaban/testkeyholder/video"
+)
+
+// instantArticlesTemplates override the default templates with the Instant Articles markup.
+// Instant Articles know h1 and h2 only, the lower headings and subheads are rendered as h2.
+const instantArticlesTemplates = `
+{{define "mitem"}}<!doctype html>
+<html lang="{{.Language}}" prefix="op: http://media.facebook.com/op#">
+<head>
+<meta charset="utf-8">
+<link rel="canonical" href="{{.CanonicalURL}}">
+<meta property="op:markup_version" content="v1.0">
+</head>
+<body>
+<article>
+<header>
+<h1>{{.Headline}}</h1>
+<time class="op-published" datetime="{{isoDate .Mitem.CreationDate}}">{{isoDate .Mitem.CreationDate}}</time>
+{{range .Authors}}<address>{{.}}</address>
+{{end}}{{with .MainImage}}{{template "image" .}}{{end}}</header>
+{{.Body}}{{range .Mitem.Meta.Analytics}}{{if eq .Type "ga"}}<figure class="op-tracker"><iframe><script>
+(function(i,s,o,g,r,a,m){i['GoogleAnalyticsObject']=r;i[r]=i[r]||function(){(i[r].q=i[r].q||[]).push(arguments)},i[r].l=1*new Date();a=s.createElement(o),m=s.getElementsByTagName(o)[0];a.async=1;a.src=g;m.parentNode.insertBefore(a,m)})(window,document,'script','https://www.google-analytics.com/analytics.js','ga');
+ga('create', {{.ID}}, 'auto');
+ga('set', 'campaignSource', 'Facebook');
+ga('set', 'campaignMedium', 'Social Instant Article');
+ga('send', 'pageview');
+</script></iframe></figure>
+{{end}}{{end}}</article>
+</body>
+</html>
+{{end}}
+
+{{define "heading"}}{{if eq .Level 1}}<h1>{{.Content}}</h1>{{else}}<h2>{{.Content}}</h2>{{end}}
+{{end}}
+
+{{define "info"}}<blockquote>{{.Content}}</blockquote>
+{{end}}
+
+{{define "subhead"}}<h2>{{.Content}}</h2>
+{{end}}
+
+{{define "image"}}<figure><img src="{{.Source}}">{{if .Caption}}<figcaption>{{.Caption}}</figcaption>{{end}}</figure>
+{{end}}
+
+{{define "video"}}{{if eq .Provider "mp4"}}<figure><video><source src="{{.EmbedURL}}" type="video/mp4"></video></figure>
+{{else if and .EmbedURL (not .File)}}<figure class="op-interactive"><iframe src="{{.EmbedURL}}" width="560" height="315"></iframe></figure>
+{{end}}{{end}}
+
+{{define "gallery"}}{{if .Images}}<figure class="op-slideshow">
+{{range .Images}}{{template "image" .}}{{end}}</figure>
+{{end}}{{end}}
+`
+
+// instantArticlesExporter exports mitems to Facebook Instant Articles markup
+type instantArticlesExporter struct {
+	r *Renderer
+}
+
+var _ Exporter = &instantArticlesExporter{}
+
+// NewInstantArticlesExporter - ctor like function - creates Instant Articles exporter,
+// the options customise its renderer. Ads are not rendered unless an ad hook is given.
+func NewInstantArticlesExporter(opts ...Option) (Exporter, error) {
+	r, err := New(append([]Option{WithTemplates(instantArticlesTemplates), WithAdHook(nil)}, opts...)...)
+	if err != nil {
+		return nil, err
+	}
+	return &instantArticlesExporter{r: r}, nil
+}
+
+// Format implements Exporter
+func (ie *instantArticlesExporter) Format() string {
+	return FormatInstantArticles
+}
+
+// Check implements Exporter. Instant Articles are matched with the web articles
+// by the canonical link, thus it is required.
+func (ie *instantArticlesExporter) Check(m *model.TheNewMitem) []error {
+	return checkMitem(m, constraints{
+		canonicalRequired: true,
+		gaSupported:       true,
+		videoProviders:    []string{video.ProviderYoutube, video.ProviderVimeo, video.ProviderDailymotion, video.ProviderJWPlayer, video.ProviderMP4},
+	})
+}
+
+// Export implements Exporter
+func (ie *instantArticlesExporter) Export(w io.Writer, m *model.TheNewMitem) error {
+	return export(ie, w, m, func() error {
+		return ie.r.RenderMitem(w, m)
+	})
+}
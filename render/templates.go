@@ -0,0 +1,37 @@
+package render
+
+// defaultTemplates render the mitem the way the apps do.
+// Any of them can be overridden with WithTemplates, the data each template gets is documented on the *Data types.
+const defaultTemplates = `
+{{define "mitem"}}<article class="mitem">
+{{if .Headline}}<h1 class="headline">{{.Headline}}</h1>
+{{end}}{{with .MainImage}}{{template "image" .}}{{end}}{{.Body}}</article>
+{{end}}
+
+{{define "paragraph"}}<p>{{.Content}}</p>
+{{end}}
+
+{{define "heading"}}{{if eq .Level 1}}<h1>{{.Content}}</h1>{{else if eq .Level 2}}<h2>{{.Content}}</h2>{{else if eq .Level 3}}<h3>{{.Content}}</h3>{{else if eq .Level 4}}<h4>{{.Content}}</h4>{{else if eq .Level 5}}<h5>{{.Content}}</h5>{{else}}<h6>{{.Content}}</h6>{{end}}
+{{end}}
+
+{{define "info"}}<aside class="info">{{.Content}}</aside>
+{{end}}
+
+{{define "subhead"}}<p class="subhead">{{.Content}}</p>
+{{end}}
+
+{{define "image"}}<figure class="image"><img src="{{.Source}}"{{if .Width}} width="{{.Width}}"{{end}}{{if .Height}} height="{{.Height}}"{{end}} alt="{{.Alt}}">{{if .Caption}}<figcaption>{{.Caption}}</figcaption>{{end}}</figure>
+{{end}}
+
+{{define "video"}}<figure class="video video-{{.VideoType}}">{{if .File}}<video src="{{.EmbedURL}}"{{if .ThumbnailURL}} poster="{{.ThumbnailURL}}"{{end}} controls></video>{{else if .EmbedURL}}<iframe src="{{.EmbedURL}}" width="640" height="360" frameborder="0" allowfullscreen></iframe>{{else}}<a href="{{.Source}}">{{.Source}}</a>{{end}}</figure>
+{{end}}
+
+{{define "gallery"}}<div class="gallery">
+{{range .Items}}{{.}}{{end}}</div>
+{{end}}
+
+{{define "unknown"}}{{end}}
+
+{{define "ad"}}<div class="ad" data-slot="{{.Slot}}"></div>
+{{end}}
+`
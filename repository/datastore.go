@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"cloud.google.com/go/datastore"
+
+	"github.com/jedynykaban/testkeyholder/model"
+)
+
+// datastoreMitemRepository implements MitemRepository on top of Cloud Datastore
+type datastoreMitemRepository struct {
+	client *datastore.Client
+}
+
+var _ MitemRepository = &datastoreMitemRepository{}
+
+// NewDatastoreMitemRepository - ctor like function - creates a repository storing mitems in Cloud Datastore.
+// The mitem ID is the encoded datastore key.
+func NewDatastoreMitemRepository(client *datastore.Client) MitemRepository {
+	return &datastoreMitemRepository{client: client}
+}
+
+// Get implements MitemRepository
+func (r *datastoreMitemRepository) Get(ctx context.Context, id string) (*model.DatabaseMitem, error) {
+	key, err := datastore.DecodeKey(id)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to decode mitem ID %s, error = %s", id, err.Error())
+	}
+	mitem := &model.DatabaseMitem{}
+	if err := r.client.Get(ctx, key, mitem); err != nil {
+		if err == datastore.ErrNoSuchEntity {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	mitem.ID = id
+	return mitem, nil
+}
+
+// Put implements MitemRepository
+func (r *datastoreMitemRepository) Put(ctx context.Context, mitem *model.DatabaseMitem) (string, error) {
+	key := datastore.IncompleteKey(MitemKind, nil)
+	if len(mitem.ID) > 0 {
+		var err error
+		key, err = datastore.DecodeKey(mitem.ID)
+		if err != nil {
+			return "", fmt.Errorf("Unable to decode mitem ID %s, error = %s", mitem.ID, err.Error())
+		}
+	}
+	key, err := r.client.Put(ctx, key, mitem)
+	if err != nil {
+		return "", err
+	}
+	mitem.ID = key.Encode()
+	return mitem.ID, nil
+}
+
+// Delete implements MitemRepository
+func (r *datastoreMitemRepository) Delete(ctx context.Context, id string) error {
+	key, err := datastore.DecodeKey(id)
+	if err != nil {
+		return fmt.Errorf("Unable to decode mitem ID %s, error = %s", id, err.Error())
+	}
+	return r.client.Delete(ctx, key)
+}
+
+// Query implements MitemRepository
+func (r *datastoreMitemRepository) Query(ctx context.Context, q MitemQuery) ([]*model.DatabaseMitem, error) {
+	query := datastore.NewQuery(MitemKind)
+	if len(q.SourceURL) > 0 {
+		query = query.Filter("SourceURL =", q.SourceURL)
+	}
+	if len(q.Slug) > 0 {
+		query = query.Filter("Slug =", q.Slug)
+	}
+	if len(q.SourceID) > 0 {
+		query = query.Filter("SourceID =", q.SourceID)
+	}
+	if q.Status != nil {
+		query = query.Filter("Status =", *q.Status)
+	}
+	if q.Limit > 0 {
+		query = query.Limit(q.Limit)
+	}
+	var ret []*model.DatabaseMitem
+	keys, err := r.client.GetAll(ctx, query, &ret)
+	if err != nil {
+		return nil, err
+	}
+	for idx, key := range keys {
+		ret[idx].ID = key.Encode()
+	}
+	return ret, nil
+}
@@ -0,0 +1,46 @@
+package repository_test
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"cloud.google.com/go/datastore"
+
+	"github.com/jedynykaban/testkeyholder/repository"
+	"github.com/jedynykaban/testkeyholder/repository/repotest"
+)
+
+// TestDatastoreMitemRepository runs against the Datastore emulator only, start it with strong consistency:
+//
+//	gcloud beta emulators datastore start --consistency=1.0
+//	$(gcloud beta emulators datastore env-init)
+func TestDatastoreMitemRepository(t *testing.T) {
+	if len(os.Getenv("DATASTORE_EMULATOR_HOST")) == 0 {
+		t.Skip("DATASTORE_EMULATOR_HOST not set, skipping the datastore repository tests")
+	}
+	projectID := os.Getenv("DATASTORE_PROJECT_ID")
+	if len(projectID) == 0 {
+		projectID = "testkeyholder-test"
+	}
+	ctx := context.Background()
+	client, err := datastore.NewClient(ctx, projectID)
+	if err != nil {
+		t.Fatalf("Unable to connect to the datastore emulator, error = %v", err)
+	}
+	defer client.Close()
+
+	repotest.TestMitemRepository(t, func() repository.MitemRepository {
+		// the suite expects an empty repository, thus the mitems stored by the previous subtest are removed
+		keys, err := client.GetAll(ctx, datastore.NewQuery(repository.MitemKind).KeysOnly(), nil)
+		if err != nil {
+			t.Fatalf("Unable to list stored mitems, error = %v", err)
+		}
+		for _, key := range keys {
+			if err := client.Delete(ctx, key); err != nil {
+				t.Fatalf("Unable to delete stored mitem, error = %v", err)
+			}
+		}
+		return repository.NewDatastoreMitemRepository(client)
+	})
+}
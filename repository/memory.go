@@ -0,0 +1,113 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"sync"
+
+	"github.com/jedynykaban/testkeyholder/model"
+)
+
+// memoryMitemRepository implements MitemRepository keeping the mitems in memory.
+// It is meant for tests and local runs.
+type memoryMitemRepository struct {
+	mu     sync.RWMutex
+	mitems map[string]*model.DatabaseMitem
+	// order keeps IDs in the insertion order, so queries are deterministic
+	order  []string
+	nextID int64
+}
+
+var _ MitemRepository = &memoryMitemRepository{}
+
+// NewMemoryMitemRepository - ctor like function - creates an empty in-memory repository
+func NewMemoryMitemRepository() MitemRepository {
+	return &memoryMitemRepository{mitems: make(map[string]*model.DatabaseMitem)}
+}
+
+// Get implements MitemRepository
+func (r *memoryMitemRepository) Get(ctx context.Context, id string) (*model.DatabaseMitem, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	mitem, ok := r.mitems[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return copyMitem(mitem), nil
+}
+
+// Put implements MitemRepository
+func (r *memoryMitemRepository) Put(ctx context.Context, mitem *model.DatabaseMitem) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(mitem.ID) == 0 {
+		r.nextID++
+		mitem.ID = strconv.FormatInt(r.nextID, 10)
+	}
+	if _, ok := r.mitems[mitem.ID]; !ok {
+		r.order = append(r.order, mitem.ID)
+	}
+	r.mitems[mitem.ID] = copyMitem(mitem)
+	return mitem.ID, nil
+}
+
+// Delete implements MitemRepository
+func (r *memoryMitemRepository) Delete(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.mitems[id]; !ok {
+		return nil
+	}
+	delete(r.mitems, id)
+	for idx, oid := range r.order {
+		if oid == id {
+			r.order = append(r.order[:idx], r.order[idx+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+// Query implements MitemRepository
+func (r *memoryMitemRepository) Query(ctx context.Context, q MitemQuery) ([]*model.DatabaseMitem, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var ret []*model.DatabaseMitem
+	for _, id := range r.order {
+		mitem := r.mitems[id]
+		if !matches(mitem, q) {
+			continue
+		}
+		ret = append(ret, copyMitem(mitem))
+		if q.Limit > 0 && len(ret) == q.Limit {
+			break
+		}
+	}
+	return ret, nil
+}
+
+func matches(mitem *model.DatabaseMitem, q MitemQuery) bool {
+	if len(q.SourceURL) > 0 && mitem.SourceURL != q.SourceURL {
+		return false
+	}
+	if len(q.Slug) > 0 && mitem.Slug != q.Slug {
+		return false
+	}
+	if len(q.SourceID) > 0 && mitem.SourceID != q.SourceID {
+		return false
+	}
+	if q.Status != nil && mitem.Status != *q.Status {
+		return false
+	}
+	return true
+}
+
+// copyMitem makes a deep copy so the stored mitems cannot be changed from outside
+func copyMitem(mitem *model.DatabaseMitem) *model.DatabaseMitem {
+	ret := *mitem
+	if mitem.Data != nil {
+		ret.Data = append(json.RawMessage(nil), mitem.Data...)
+	}
+	return &ret
+}
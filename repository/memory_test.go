@@ -0,0 +1,12 @@
+package repository_test
+
+import (
+	"testing"
+
+	"github.com/jedynykaban/testkeyholder/repository"
+	"github.com/jedynykaban/testkeyholder/repository/repotest"
+)
+
+func TestMemoryMitemRepository(t *testing.T) {
+	repotest.TestMitemRepository(t, repository.NewMemoryMitemRepository)
+}
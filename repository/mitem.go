@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jedynykaban/testkeyholder/model"
+	"github.com/jedynykaban/testkeyholder/slug"
+)
+
+// MitemKind is the datastore kind the mitems are stored as
+const MitemKind = "Mitem"
+
+// ErrNotFound is returned when the requested mitem does not exist
+var ErrNotFound = errors.New("Mitem not found")
+
+// MitemQuery describes which mitems to look for.
+// Empty fields are not used as filters, all the set ones have to match.
+type MitemQuery struct {
+	SourceURL string
+	Slug      string
+	SourceID  string
+	// Status is a pointer since 0 is a valid status
+	Status *int
+	// Limit caps the number of results, 0 means no limit
+	Limit int
+}
+
+// MitemRepository stores DatabaseMitem structures
+type MitemRepository interface {
+	// Get returns the mitem with the given ID or ErrNotFound
+	Get(ctx context.Context, id string) (*model.DatabaseMitem, error)
+	// Put stores the mitem. A mitem without ID gets a new one,
+	// the ID is set on the passed mitem and returned.
+	Put(ctx context.Context, mitem *model.DatabaseMitem) (string, error)
+	// Delete removes the mitem, deleting a mitem that does not exist is not an error
+	Delete(ctx context.Context, id string) error
+	// Query returns all the mitems matching the query
+	Query(ctx context.Context, q MitemQuery) ([]*model.DatabaseMitem, error)
+}
+
+// Status is a helper allowing one to set MitemQuery.Status in place
+func Status(status int) *int {
+	return &status
+}
+
+// slugChecker tells whether a slug is taken by any of the stored mitems
+type slugChecker struct {
+	repo MitemRepository
+}
+
+// SlugChecker - ctor like function - creates slug.UniquenessChecker backed by the repository
+func SlugChecker(repo MitemRepository) slug.UniquenessChecker {
+	return &slugChecker{repo: repo}
+}
+
+// SlugExists implements slug.UniquenessChecker
+func (sc *slugChecker) SlugExists(ctx context.Context, s string) (bool, error) {
+	mitems, err := sc.repo.Query(ctx, MitemQuery{Slug: s, Limit: 1})
+	if err != nil {
+		return false, err
+	}
+	return len(mitems) > 0, nil
+}
@@ -0,0 +1,220 @@
+// Package repotest provides the conformance suite every MitemRepository implementation has to pass.
+package repotest
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/jedynykaban/testkeyholder/model"
+	"github.com/jedynykaban/testkeyholder/repository"
+)
+
+// TestMitemRepository runs the conformance suite against repositories created by newRepo.
+// Every subtest gets a fresh, empty repository.
+//
+// Usage from a _test.go file:
+//
+//	func TestMemoryMitemRepository(t *testing.T) {
+//		repotest.TestMitemRepository(t, repository.NewMemoryMitemRepository)
+//	}
+func TestMitemRepository(t *testing.T, newRepo func() repository.MitemRepository) {
+	tests := []struct {
+		name string
+		test func(t *testing.T, repo repository.MitemRepository)
+	}{
+		{"PutAssignsID", testPutAssignsID},
+		{"GetReturnsStored", testGetReturnsStored},
+		{"GetMissing", testGetMissing},
+		{"PutUpdates", testPutUpdates},
+		{"Delete", testDelete},
+		{"QueryFilters", testQueryFilters},
+		{"QueryLimit", testQueryLimit},
+		{"ReturnedCopies", testReturnedCopies},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.test(t, newRepo())
+		})
+	}
+}
+
+func sampleMitem(sourceURL, slug, sourceID string, status int) *model.DatabaseMitem {
+	return &model.DatabaseMitem{
+		Data:      json.RawMessage(`{"headline":"` + slug + `"}`),
+		SourceURL: sourceURL,
+		LogoURL:   "https://example.com/logo.png",
+		SourceID:  sourceID,
+		Slug:      slug,
+		Status:    status,
+	}
+}
+
+func mustPut(t *testing.T, repo repository.MitemRepository, mitem *model.DatabaseMitem) string {
+	id, err := repo.Put(context.Background(), mitem)
+	if err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+	return id
+}
+
+func testPutAssignsID(t *testing.T, repo repository.MitemRepository) {
+	mitem := sampleMitem("https://example.com/a", "a", "src", 0)
+	id := mustPut(t, repo, mitem)
+	if len(id) == 0 {
+		t.Fatalf("Put() returned empty ID")
+	}
+	if mitem.ID != id {
+		t.Errorf("Put() mitem.ID = %v, want = %v", mitem.ID, id)
+	}
+	other := mustPut(t, repo, sampleMitem("https://example.com/b", "b", "src", 0))
+	if other == id {
+		t.Errorf("Put() assigned the same ID %v twice", id)
+	}
+}
+
+func testGetReturnsStored(t *testing.T, repo repository.MitemRepository) {
+	mitem := sampleMitem("https://example.com/a", "a", "src", model.StatusDelete)
+	mitem.UserEdited = true
+	id := mustPut(t, repo, mitem)
+	got, err := repo.Get(context.Background(), id)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if got.ID != id || got.SourceURL != mitem.SourceURL || got.Slug != mitem.Slug ||
+		got.SourceID != mitem.SourceID || got.LogoURL != mitem.LogoURL ||
+		got.Status != mitem.Status || got.UserEdited != mitem.UserEdited ||
+		string(got.Data) != string(mitem.Data) {
+		t.Errorf("Get() = %+v, want = %+v", got, mitem)
+	}
+}
+
+func testGetMissing(t *testing.T, repo repository.MitemRepository) {
+	id := mustPut(t, repo, sampleMitem("https://example.com/a", "a", "src", 0))
+	if err := repo.Delete(context.Background(), id); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, err := repo.Get(context.Background(), id); err != repository.ErrNotFound {
+		t.Errorf("Get() error = %v, want = %v", err, repository.ErrNotFound)
+	}
+}
+
+func testPutUpdates(t *testing.T, repo repository.MitemRepository) {
+	mitem := sampleMitem("https://example.com/a", "a", "src", 0)
+	id := mustPut(t, repo, mitem)
+	mitem.Slug = "changed"
+	if again := mustPut(t, repo, mitem); again != id {
+		t.Errorf("Put() of existing mitem ID = %v, want = %v", again, id)
+	}
+	got, err := repo.Get(context.Background(), id)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if got.Slug != "changed" {
+		t.Errorf("Get() slug = %v, want = changed", got.Slug)
+	}
+	all, err := repo.Query(context.Background(), repository.MitemQuery{})
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	if len(all) != 1 {
+		t.Errorf("Query() returned %d mitems, want = 1", len(all))
+	}
+}
+
+func testDelete(t *testing.T, repo repository.MitemRepository) {
+	keep := mustPut(t, repo, sampleMitem("https://example.com/a", "a", "src", 0))
+	drop := mustPut(t, repo, sampleMitem("https://example.com/b", "b", "src", 0))
+	if err := repo.Delete(context.Background(), drop); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if err := repo.Delete(context.Background(), drop); err != nil {
+		t.Errorf("Delete() of deleted mitem error = %v, want = nil", err)
+	}
+	if _, err := repo.Get(context.Background(), keep); err != nil {
+		t.Errorf("Get() of kept mitem error = %v", err)
+	}
+}
+
+func testQueryFilters(t *testing.T, repo repository.MitemRepository) {
+	a := mustPut(t, repo, sampleMitem("https://example.com/a", "a", "svt", 0))
+	b := mustPut(t, repo, sampleMitem("https://example.com/b", "b", "svt", model.StatusDelete))
+	c := mustPut(t, repo, sampleMitem("https://example.com/c", "c", "dn", 0))
+
+	tests := []struct {
+		name  string
+		query repository.MitemQuery
+		want  []string
+	}{
+		{"all", repository.MitemQuery{}, []string{a, b, c}},
+		{"sourceURL", repository.MitemQuery{SourceURL: "https://example.com/b"}, []string{b}},
+		{"slug", repository.MitemQuery{Slug: "c"}, []string{c}},
+		{"sourceID", repository.MitemQuery{SourceID: "svt"}, []string{a, b}},
+		{"status zero", repository.MitemQuery{Status: repository.Status(0)}, []string{a, c}},
+		{"status delete", repository.MitemQuery{Status: repository.Status(model.StatusDelete)}, []string{b}},
+		{"combined", repository.MitemQuery{SourceID: "svt", Status: repository.Status(0)}, []string{a}},
+		{"no match", repository.MitemQuery{Slug: "missing"}, nil},
+	}
+	for _, tt := range tests {
+		got, err := repo.Query(context.Background(), tt.query)
+		if err != nil {
+			t.Fatalf("Query(%s) error = %v", tt.name, err)
+		}
+		if !sameIDs(got, tt.want) {
+			t.Errorf("Query(%s) = %v, want = %v", tt.name, ids(got), tt.want)
+		}
+	}
+}
+
+func testQueryLimit(t *testing.T, repo repository.MitemRepository) {
+	for _, slug := range []string{"a", "b", "c"} {
+		mustPut(t, repo, sampleMitem("https://example.com/"+slug, slug, "svt", 0))
+	}
+	got, err := repo.Query(context.Background(), repository.MitemQuery{SourceID: "svt", Limit: 2})
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	if len(got) != 2 {
+		t.Errorf("Query() returned %d mitems, want = 2", len(got))
+	}
+}
+
+func testReturnedCopies(t *testing.T, repo repository.MitemRepository) {
+	id := mustPut(t, repo, sampleMitem("https://example.com/a", "a", "src", 0))
+	got, err := repo.Get(context.Background(), id)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	got.Slug = "changed"
+	again, err := repo.Get(context.Background(), id)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if again.Slug != "a" {
+		t.Errorf("Get() slug = %v after modifying returned mitem, want = a", again.Slug)
+	}
+}
+
+func ids(mitems []*model.DatabaseMitem) []string {
+	var ret []string
+	for _, m := range mitems {
+		ret = append(ret, m.ID)
+	}
+	return ret
+}
+
+func sameIDs(mitems []*model.DatabaseMitem, want []string) bool {
+	got := make(map[string]int)
+	for _, id := range ids(mitems) {
+		got[id]++
+	}
+	if len(mitems) != len(want) {
+		return false
+	}
+	for _, id := range want {
+		if got[id] != 1 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,275 @@
+// Package sanitize cleans the HTML of the body text elements.
+// Only the inline tags allowed by the policy are kept, everything else is stripped
+// and the markup is balanced. All the changes made are reported.
+package sanitize
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+)
+
+// Actions the sanitiser reports
+const (
+	// ActionRemoved means the tag, the attribute or the element with its content was dropped
+	ActionRemoved = "removed"
+	// ActionClosed means a closing tag was added to balance the markup
+	ActionClosed = "closed"
+)
+
+// Change describes a single change made by the sanitiser
+type Change struct {
+	Action string `json:"action"`
+	// Tag the change concerns, empty for comments
+	Tag string `json:"tag,omitempty"`
+	// Attribute removed, empty when the change concerns the whole tag
+	Attribute string `json:"attribute,omitempty"`
+	Reason    string `json:"reason"`
+}
+
+// String returns human readable description of the change
+func (c Change) String() string {
+	switch {
+	case len(c.Attribute) > 0:
+		return fmt.Sprintf("attribute %s of <%s> %s: %s", c.Attribute, c.Tag, c.Action, c.Reason)
+	case len(c.Tag) > 0:
+		return fmt.Sprintf("<%s> %s: %s", c.Tag, c.Action, c.Reason)
+	}
+	return fmt.Sprintf("%s: %s", c.Action, c.Reason)
+}
+
+// Policy tells which tags and attributes are kept
+type Policy struct {
+	// Tags maps the allowed tags to their allowed attributes
+	Tags map[string][]string
+	// URLAttributes are checked against Schemes
+	URLAttributes []string
+	// Schemes allowed in the URL attributes, relative URLs are always allowed
+	Schemes []string
+	// Void tags have no content and no closing tag
+	Void []string
+	// Dropped tags are removed along with their content
+	Dropped []string
+}
+
+// DefaultPolicy keeps b, i, em, strong, br and links with safe href
+var DefaultPolicy = &Policy{
+	Tags: map[string][]string{
+		"b":      nil,
+		"i":      nil,
+		"em":     nil,
+		"strong": nil,
+		"br":     nil,
+		"a":      {"href"},
+	},
+	URLAttributes: []string{"href"},
+	Schemes:       []string{"http", "https", "mailto"},
+	Void:          []string{"br"},
+	Dropped:       []string{"script", "style", "iframe", "object", "embed", "noscript", "template", "svg", "math", "head", "title"},
+}
+
+// rawTextTags hold text that is not markup, it is skipped up to the closing tag
+var rawTextTags = map[string]bool{"script": true, "style": true, "iframe": true, "noscript": true, "title": true}
+
+var entity = regexp.MustCompile(`^&(#[0-9]{1,7}|#[xX][0-9a-fA-F]{1,6}|[a-zA-Z][a-zA-Z0-9]{1,31});`)
+
+var schemeChars = regexp.MustCompile(`^([a-zA-Z][a-zA-Z0-9+.\-]*):`)
+
+// Sanitize cleans the HTML fragment with the DefaultPolicy
+func Sanitize(fragment string) (string, []Change) {
+	return DefaultPolicy.Sanitize(fragment)
+}
+
+// Sanitize cleans the HTML fragment and reports the changes made.
+// Text is escaped where needed, well formed entities are kept.
+func (p *Policy) Sanitize(fragment string) (string, []Change) {
+	s := &sanitizer{policy: p}
+	z := &tokenizer{s: fragment}
+	for {
+		t, ok := z.next()
+		if !ok {
+			break
+		}
+		switch t.typ {
+		case textToken:
+			s.text(t.data)
+		case commentToken:
+			s.change(Change{Action: ActionRemoved, Reason: "comment"})
+		case unterminatedTagToken:
+			s.change(Change{Action: ActionRemoved, Tag: t.data, Reason: "unterminated tag"})
+		case startTagToken, selfClosingTagToken:
+			if contains(p.Dropped, t.data) {
+				s.drop(z, t)
+				continue
+			}
+			s.start(t)
+		case endTagToken:
+			s.end(t.data)
+		}
+	}
+	for len(s.open) > 0 {
+		s.close("unclosed tag")
+	}
+	return s.out.String(), s.changes
+}
+
+type sanitizer struct {
+	policy  *Policy
+	out     strings.Builder
+	open    []string
+	changes []Change
+}
+
+func (s *sanitizer) change(c Change) {
+	s.changes = append(s.changes, c)
+}
+
+// text writes the text escaping everything but the well formed entities
+func (s *sanitizer) text(data string) {
+	for len(data) > 0 {
+		idx := strings.IndexAny(data, "<>&\"")
+		if idx < 0 {
+			s.out.WriteString(data)
+			return
+		}
+		s.out.WriteString(data[:idx])
+		data = data[idx:]
+		if data[0] == '&' {
+			if m := entity.FindString(data); len(m) > 0 {
+				s.out.WriteString(m)
+				data = data[len(m):]
+				continue
+			}
+		}
+		s.out.WriteString(html.EscapeString(data[:1]))
+		data = data[1:]
+	}
+}
+
+// drop removes the element along with its content
+func (s *sanitizer) drop(z *tokenizer, t token) {
+	s.change(Change{Action: ActionRemoved, Tag: t.data, Reason: "element removed with its content"})
+	if t.typ == selfClosingTagToken || contains(s.policy.Void, t.data) {
+		return
+	}
+	if rawTextTags[t.data] {
+		z.rawText(t.data)
+		return
+	}
+	depth := 1
+	for depth > 0 {
+		next, ok := z.next()
+		if !ok {
+			return
+		}
+		if next.data != t.data {
+			continue
+		}
+		switch next.typ {
+		case startTagToken:
+			depth++
+		case endTagToken:
+			depth--
+		}
+	}
+}
+
+func (s *sanitizer) start(t token) {
+	allowedAttrs, ok := s.policy.Tags[t.data]
+	if !ok {
+		s.change(Change{Action: ActionRemoved, Tag: t.data, Reason: "tag not allowed"})
+		return
+	}
+	if t.data == "a" && (contains(s.open, "a") || contains(s.open, "")) {
+		s.change(Change{Action: ActionRemoved, Tag: t.data, Reason: "nested link"})
+		s.open = append(s.open, "")
+		return
+	}
+	var attrs strings.Builder
+	for _, attr := range t.attrs {
+		switch {
+		case strings.HasPrefix(attr.name, "on"):
+			s.change(Change{Action: ActionRemoved, Tag: t.data, Attribute: attr.name, Reason: "event handler"})
+		case !contains(allowedAttrs, attr.name):
+			s.change(Change{Action: ActionRemoved, Tag: t.data, Attribute: attr.name, Reason: "attribute not allowed"})
+		case contains(s.policy.URLAttributes, attr.name) && !s.policy.safeURL(attr.value):
+			s.change(Change{Action: ActionRemoved, Tag: t.data, Attribute: attr.name, Reason: "unsafe URL"})
+		default:
+			fmt.Fprintf(&attrs, " %s=\"%s\"", attr.name, html.EscapeString(html.UnescapeString(attr.value)))
+		}
+	}
+	if t.data == "a" && attrs.Len() == 0 {
+		s.change(Change{Action: ActionRemoved, Tag: t.data, Reason: "link without safe href"})
+		// the empty name marks the dropped link, so its closing tag is dropped silently
+		s.open = append(s.open, "")
+		return
+	}
+	s.out.WriteString("<" + t.data + attrs.String() + ">")
+	if !contains(s.policy.Void, t.data) && t.typ != selfClosingTagToken {
+		s.open = append(s.open, t.data)
+	}
+}
+
+func (s *sanitizer) end(name string) {
+	if _, ok := s.policy.Tags[name]; !ok {
+		// the start tag was reported already
+		return
+	}
+	if contains(s.policy.Void, name) {
+		return
+	}
+	idx := len(s.open) - 1
+	for ; idx >= 0; idx-- {
+		if s.open[idx] == name || (name == "a" && s.open[idx] == "") {
+			break
+		}
+	}
+	if idx < 0 {
+		s.change(Change{Action: ActionRemoved, Tag: name, Reason: "closing tag without opening one"})
+		return
+	}
+	for len(s.open)-1 > idx {
+		s.close("misnested tag")
+	}
+	if s.open[idx] != "" {
+		s.out.WriteString("</" + name + ">")
+	}
+	s.open = s.open[:idx]
+}
+
+// close closes the innermost open tag
+func (s *sanitizer) close(reason string) {
+	name := s.open[len(s.open)-1]
+	s.open = s.open[:len(s.open)-1]
+	if len(name) == 0 {
+		return
+	}
+	s.out.WriteString("</" + name + ">")
+	s.change(Change{Action: ActionClosed, Tag: name, Reason: reason})
+}
+
+// safeURL allows relative URLs and the URLs of the allowed schemes
+func (p *Policy) safeURL(value string) bool {
+	value = strings.Map(func(r rune) rune {
+		// browsers ignore whitespace and control characters in the scheme
+		if r <= ' ' || r == 0x7f {
+			return -1
+		}
+		return r
+	}, html.UnescapeString(value))
+	m := schemeChars.FindStringSubmatch(value)
+	if m == nil {
+		return !strings.Contains(strings.SplitN(value, "/", 2)[0], ":")
+	}
+	return contains(p.Schemes, strings.ToLower(m[1]))
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
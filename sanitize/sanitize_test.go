@@ -0,0 +1,68 @@
+package sanitize
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSanitize(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      string
+		want    string
+		changes int
+	}{
+		{"allowed markup", `<b>bold</b> <i>it</i> <a href="https://svt.se/a?x=1&amp;y=2">link</a><br>`, `<b>bold</b> <i>it</i> <a href="https://svt.se/a?x=1&amp;y=2">link</a><br>`, 0},
+		{"text escaped", `1 < 2 & "3" > 0 &amp; &#228; &bogus`, `1 &lt; 2 &amp; &#34;3&#34; &gt; 0 &amp; &#228; &amp;bogus`, 0},
+		{"script", `a<script>alert("<b>x</b>")</script>b`, `ab`, 1},
+		{"uppercase script", `a<SCRIPT>alert(1)</SCRIPT>b`, `ab`, 1},
+		{"unterminated script", `a<script>alert(1)`, `a`, 1},
+		{"nested dropped", `a<svg><svg><script>x</script></svg>y</svg>b`, `ab`, 1},
+		{"event handler", `<b onclick="alert(1)">x</b>`, `<b>x</b>`, 1},
+		{"javascript href", `<a href="javascript:alert(1)">x</a>`, `x`, 2},
+		{"obfuscated javascript href", `<a href="jav&#x09;ascript:alert(1)">x</a>`, `x`, 2},
+		{"entity encoded scheme", `<a href="&#106;avascript:alert(1)">x</a>`, `x`, 2},
+		{"data href", `<a href="data:text/html,<script>alert(1)</script>">x</a>`, `x`, 2},
+		{"relative href", `<a href="/nyheter/a">x</a>`, `<a href="/nyheter/a">x</a>`, 0},
+		{"attribute not allowed", `<a href="/a" style="x" target="_blank">x</a>`, `<a href="/a">x</a>`, 2},
+		{"attribute breakout", `<a href='/a"onmouseover="alert(1)'>x</a>`, `<a href="/a&#34;onmouseover=&#34;alert(1)">x</a>`, 0},
+		{"tag not allowed", `<div><p>x</p></div>`, `x`, 2},
+		{"img onerror", `<img src=x onerror=alert(1)>x`, `x`, 1},
+		{"comment", `a<!-- <script>alert(1)</script> -->b`, `ab`, 1},
+		{"unclosed", `<b><i>x`, `<b><i>x</i></b>`, 2},
+		{"misnested", `<b><i>x</b>y</i>`, `<b><i>x</i></b>y`, 2},
+		{"stray closing tag", `x</b>`, `x`, 1},
+		{"nested link", `<a href="/a">x<a href="/b">y</a>z</a>`, `<a href="/a">xyz</a>`, 1},
+		{"unterminated tag", `x<b`, `x`, 1},
+	}
+	for _, tt := range tests {
+		got, changes := Sanitize(tt.in)
+		if got != tt.want {
+			t.Errorf("%s: Sanitize(%q) got = %q, want = %q", tt.name, tt.in, got, tt.want)
+		}
+		if len(changes) != tt.changes {
+			t.Errorf("%s: Sanitize(%q) changes got = %v, want %d", tt.name, tt.in, changes, tt.changes)
+		}
+		if strings.Contains(strings.ToLower(got), "<script") || strings.Contains(strings.ToLower(got), "javascript:") {
+			t.Errorf("%s: Sanitize(%q) left unsafe markup %q", tt.name, tt.in, got)
+		}
+	}
+}
+
+func TestText(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{`<b>Hello</b>,  <i>world</i>!`, "Hello, world!"},
+		{`one<br>two`, "one two"},
+		{`R&auml;ksm&ouml;rg&aring;s &amp; <a href="/a">&#246;l</a>`, "Räksmörgås & öl"},
+		{`a<script>var x = "<b>y</b>";</script> b`, "a b"},
+		{`<p>first</p>  <p>second</p>`, "first second"},
+	}
+	for _, tt := range tests {
+		if got := Text(tt.in); got != tt.want {
+			t.Errorf("Text(%q) got = %q, want = %q", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package sanitize
+
+import (
+	"html"
+	"strings"
+)
+
+// Text strips the markup from the fragment with the DefaultPolicy, see Policy.Text
+func Text(fragment string) string {
+	return DefaultPolicy.Text(fragment)
+}
+
+// Text strips all the markup from the fragment leaving plain text with the entities decoded.
+// The content of the elements dropped by the policy i.e. scripts is dropped as well,
+// whitespace is collapsed into single spaces.
+func (p *Policy) Text(fragment string) string {
+	var out strings.Builder
+	z := &tokenizer{s: fragment}
+	for {
+		t, ok := z.next()
+		if !ok {
+			break
+		}
+		switch t.typ {
+		case textToken:
+			out.WriteString(html.UnescapeString(t.data))
+		case startTagToken, selfClosingTagToken:
+			if contains(p.Dropped, t.data) {
+				s := &sanitizer{policy: p}
+				s.drop(z, t)
+				continue
+			}
+			if contains(p.Void, t.data) {
+				out.WriteByte(' ')
+			}
+		}
+	}
+	return strings.Join(strings.Fields(out.String()), " ")
+}
@@ -0,0 +1,196 @@
+package sanitize
+
+import (
+	"strings"
+)
+
+type tokenType int
+
+const (
+	textToken tokenType = iota
+	startTagToken
+	endTagToken
+	selfClosingTagToken
+	commentToken
+	// unterminatedTagToken is a tag cut off by the end of the fragment
+	unterminatedTagToken
+)
+
+type attribute struct {
+	name  string
+	value string
+}
+
+type token struct {
+	typ tokenType
+	// data holds the text or the lowercased tag name
+	data  string
+	attrs []attribute
+}
+
+// tokenizer splits the HTML fragment into tokens.
+// It is forgiving: anything that does not look like markup is returned as text.
+type tokenizer struct {
+	s   string
+	pos int
+}
+
+func (z *tokenizer) next() (token, bool) {
+	if z.pos >= len(z.s) {
+		return token{}, false
+	}
+	if z.s[z.pos] == '<' {
+		if t, ok := z.markup(); ok {
+			return t, true
+		}
+		// a lone < is text
+		z.pos++
+		return token{typ: textToken, data: "<"}, true
+	}
+	end := strings.IndexByte(z.s[z.pos:], '<')
+	if end < 0 {
+		end = len(z.s) - z.pos
+	}
+	t := token{typ: textToken, data: z.s[z.pos : z.pos+end]}
+	z.pos += end
+	return t, true
+}
+
+// rawText returns everything up to the closing tag of the element, the closing tag is consumed.
+// It is used for the elements whose content is not markup i.e. script and style.
+func (z *tokenizer) rawText(name string) string {
+	rest := z.s[z.pos:]
+	idx := strings.Index(strings.ToLower(rest), "</"+name)
+	if idx < 0 {
+		z.pos = len(z.s)
+		return rest
+	}
+	z.pos += idx
+	if end := strings.IndexByte(z.s[z.pos:], '>'); end >= 0 {
+		z.pos += end + 1
+	} else {
+		z.pos = len(z.s)
+	}
+	return rest[:idx]
+}
+
+// markup reads the tag or the comment starting at the current position
+func (z *tokenizer) markup() (token, bool) {
+	rest := z.s[z.pos:]
+	if strings.HasPrefix(rest, "<!--") {
+		end := strings.Index(rest[4:], "-->")
+		if end < 0 {
+			z.pos = len(z.s)
+			return token{typ: commentToken}, true
+		}
+		z.pos += 4 + end + 3
+		return token{typ: commentToken}, true
+	}
+	if strings.HasPrefix(rest, "<!") || strings.HasPrefix(rest, "<?") {
+		end := strings.IndexByte(rest, '>')
+		if end < 0 {
+			z.pos = len(z.s)
+		} else {
+			z.pos += end + 1
+		}
+		return token{typ: commentToken}, true
+	}
+
+	i := 1
+	typ := startTagToken
+	if i < len(rest) && rest[i] == '/' {
+		typ = endTagToken
+		i++
+	}
+	start := i
+	for i < len(rest) && isNameByte(rest[i]) {
+		i++
+	}
+	if i == start || !isLetter(rest[start]) {
+		return token{}, false
+	}
+	t := token{typ: typ, data: strings.ToLower(rest[start:i])}
+	for {
+		i = skipSpace(rest, i)
+		if i >= len(rest) {
+			// unterminated tag swallows the rest of the fragment
+			z.pos = len(z.s)
+			t.typ = unterminatedTagToken
+			return t, true
+		}
+		switch rest[i] {
+		case '>':
+			z.pos += i + 1
+			return t, true
+		case '/':
+			i++
+			if i < len(rest) && rest[i] == '>' {
+				if t.typ == startTagToken {
+					t.typ = selfClosingTagToken
+				}
+				z.pos += i + 1
+				return t, true
+			}
+			continue
+		}
+		var attr attribute
+		attr, i = readAttribute(rest, i)
+		if len(attr.name) > 0 && t.typ != endTagToken {
+			t.attrs = append(t.attrs, attr)
+		}
+	}
+}
+
+func readAttribute(s string, i int) (attribute, int) {
+	start := i
+	for i < len(s) && !isSpace(s[i]) && s[i] != '=' && s[i] != '>' && s[i] != '/' {
+		i++
+	}
+	if i == start {
+		// stray character, skip it
+		return attribute{}, i + 1
+	}
+	attr := attribute{name: strings.ToLower(s[start:i])}
+	j := skipSpace(s, i)
+	if j >= len(s) || s[j] != '=' {
+		return attr, i
+	}
+	j = skipSpace(s, j+1)
+	if j >= len(s) {
+		return attr, j
+	}
+	if q := s[j]; q == '"' || q == '\'' {
+		end := strings.IndexByte(s[j+1:], q)
+		if end < 0 {
+			attr.value = s[j+1:]
+			return attr, len(s)
+		}
+		attr.value = s[j+1 : j+1+end]
+		return attr, j + 1 + end + 1
+	}
+	start = j
+	for j < len(s) && !isSpace(s[j]) && s[j] != '>' {
+		j++
+	}
+	attr.value = s[start:j]
+	return attr, j
+}
+
+func skipSpace(s string, i int) int {
+	for i < len(s) && isSpace(s[i]) {
+		i++
+	}
+	return i
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f'
+}
+
+func isLetter(c byte) bool {
+	return ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z')
+}
+
+func isNameByte(c byte) bool {
+	return isLetter(c) || ('0' <= c && c <= '9') || c == '-' || c == ':'
+}
@@ -0,0 +1,210 @@
+package schema
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jedynykaban/testkeyholder/model"
+)
+
+var (
+	rawMessageType = reflect.TypeOf(json.RawMessage{})
+	timeType       = reflect.TypeOf(time.Time{})
+)
+
+// generator makes the schema out of Go types reading their json tags.
+// Raw JSON is taken for a body element, it is described once in $defs and referenced.
+type generator struct {
+	// required lists the required properties per type name
+	required map[string][]string
+	// output makes the properties encoded unconditionally (without omitempty) required,
+	// this is what the consumers of the encoded structure can rely on
+	output bool
+	// nonEmpty rejects empty strings and arrays of the required properties
+	nonEmpty bool
+	// patterns lists the regular expressions the properties have to match,
+	// the key is type name dot property name
+	patterns map[string]string
+	defs     map[string]*Schema
+}
+
+// document makes the root schema of the value's type
+func (g *generator) document(v interface{}, title, description string) *Schema {
+	s := g.schemaOf(reflect.TypeOf(v))
+	s.Schema = Draft
+	s.Title = title
+	s.Description = description
+	s.Defs = g.defs
+	return s
+}
+
+func (g *generator) schemaOf(t reflect.Type) *Schema {
+	switch {
+	case t == rawMessageType:
+		g.defineBodyElement()
+		return &Schema{Ref: bodyElementRef}
+	case t == timeType:
+		return &Schema{Type: Types{"string"}, Format: "date-time"}
+	}
+	switch t.Kind() {
+	case reflect.Ptr:
+		return nullable(g.schemaOf(t.Elem()))
+	case reflect.String:
+		return &Schema{Type: Types{"string"}}
+	case reflect.Bool:
+		return &Schema{Type: Types{"boolean"}}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: Types{"integer"}}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: Types{"number"}}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			// encoding/json encodes byte slices with base64
+			return &Schema{Type: Types{"string"}}
+		}
+		s := &Schema{Type: Types{"array"}, Items: g.schemaOf(t.Elem())}
+		if t.Kind() == reflect.Slice {
+			return nullable(s)
+		}
+		return s
+	case reflect.Map:
+		return nullable(&Schema{Type: Types{"object"}, AdditionalProperties: g.schemaOf(t.Elem())})
+	case reflect.Struct:
+		return g.object(t)
+	}
+	// interfaces hold any value
+	return &Schema{}
+}
+
+// object describes the struct, fields of the embedded structs are inlined like encoding/json does
+func (g *generator) object(t reflect.Type) *Schema {
+	s := &Schema{Type: Types{"object"}, Properties: map[string]*Schema{}}
+	var required []string
+	if !g.output {
+		required = g.required[t.Name()]
+	}
+	g.addFields(s, t, &required)
+	for _, name := range required {
+		property, ok := s.Properties[name]
+		if !ok {
+			continue
+		}
+		s.Required = append(s.Required, name)
+		if g.nonEmpty {
+			if property.Type.has("string") {
+				property.MinLength = 1
+			}
+			if property.Type.has("array") {
+				property.Type = Types{"array"}
+				property.MinItems = 1
+			}
+		}
+	}
+	return s
+}
+
+func (g *generator) addFields(s *Schema, t reflect.Type, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, omitempty, ok := jsonName(field)
+		if !ok {
+			continue
+		}
+		if field.Anonymous && len(name) == 0 && field.Type.Kind() == reflect.Struct {
+			g.addFields(s, field.Type, required)
+			continue
+		}
+		if len(field.PkgPath) > 0 {
+			continue
+		}
+		if len(name) == 0 {
+			name = field.Name
+		}
+		property := g.schemaOf(field.Type)
+		if pattern, ok := g.patterns[t.Name()+"."+name]; ok {
+			property.Pattern = pattern
+		}
+		s.Properties[name] = property
+		if g.output && !omitempty {
+			*required = append(*required, name)
+		}
+	}
+}
+
+// defineBodyElement describes the body element: one definition per element type
+// and the catch-all one for the types we do not know about, they are passed through untouched
+func (g *generator) defineBodyElement() {
+	if _, ok := g.defs[NameBodyElement]; ok {
+		return
+	}
+	if g.defs == nil {
+		g.defs = map[string]*Schema{}
+	}
+	element := &Schema{
+		Type:       Types{"object"},
+		Properties: map[string]*Schema{"type": {Type: Types{"string"}, MinLength: 1}},
+		Required:   []string{"type"},
+	}
+	g.defs[NameBodyElement] = element
+
+	wireTypes := model.ElementWireTypes()
+	var known []interface{}
+	for elementType := range wireTypes {
+		known = append(known, elementType)
+	}
+	sort.Slice(known, func(i, j int) bool { return known[i].(string) < known[j].(string) })
+	for _, elementType := range known {
+		name := elementType.(string)
+		def := g.schemaOf(reflect.TypeOf(wireTypes[name]))
+		def.Title = name
+		def.Properties["type"] = &Schema{Type: Types{"string"}, Const: name}
+		if !contains(def.Required, "type") {
+			def.Required = append([]string{"type"}, def.Required...)
+		}
+		g.defs[name] = def
+		element.OneOf = append(element.OneOf, &Schema{Ref: "#/$defs/" + name})
+	}
+	element.OneOf = append(element.OneOf, &Schema{
+		Title:      "unknown",
+		Properties: map[string]*Schema{"type": {Not: &Schema{Enum: known}}},
+	})
+}
+
+// jsonName reads the json tag of the field, ok is false for the fields skipped by encoding/json
+func jsonName(field reflect.StructField) (name string, omitempty bool, ok bool) {
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return "", false, false
+	}
+	parts := strings.Split(tag, ",")
+	for _, option := range parts[1:] {
+		if option == "omitempty" {
+			omitempty = true
+		}
+	}
+	return parts[0], omitempty, true
+}
+
+func nullable(s *Schema) *Schema {
+	if len(s.Type) > 0 && !s.Type.has("null") {
+		s.Type = append(s.Type, "null")
+	}
+	return s
+}
+
+func (t Types) has(name string) bool {
+	return contains(t, name)
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,157 @@
+// Package schema describes the mitem formats with JSON Schema (draft 2020-12)
+// and validates raw mitems against the schemas.
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/jedynykaban/testkeyholder/model"
+	"github.com/jedynykaban/testkeyholder/video"
+)
+
+// Draft is the JSON Schema dialect of the generated documents
+const Draft = "https://json-schema.org/draft/2020-12/schema"
+
+// Names of the documents, see Document
+const (
+	NameMitemTiniest = "mitemTiniest"
+	NameTheNewMitem  = "theNewMitem"
+	NameBodyElement  = "bodyElement"
+)
+
+// bodyElementRef points at the body element definition, it is used for every body entry
+const bodyElementRef = "#/$defs/" + NameBodyElement
+
+// Schema is a JSON Schema document or subschema.
+// Only the keywords used by the generator are supported, the validator understands the same subset.
+type Schema struct {
+	Schema               string             `json:"$schema,omitempty"`
+	Title                string             `json:"title,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 Types              `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Const                interface{}        `json:"const,omitempty"`
+	Enum                 []interface{}      `json:"enum,omitempty"`
+	MinLength            int                `json:"minLength,omitempty"`
+	Pattern              string             `json:"pattern,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	MinItems             int                `json:"minItems,omitempty"`
+	OneOf                []*Schema          `json:"oneOf,omitempty"`
+	Not                  *Schema            `json:"not,omitempty"`
+	Defs                 map[string]*Schema `json:"$defs,omitempty"`
+}
+
+// Types is the value of the type keyword, a single type is encoded as a string
+type Types []string
+
+// MarshalJSON implements json.Marshaler
+func (t Types) MarshalJSON() ([]byte, error) {
+	if len(t) == 1 {
+		return json.Marshal(t[0])
+	}
+	return json.Marshal([]string(t))
+}
+
+// UnmarshalJSON implements json.Unmarshaler
+func (t *Types) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*t = Types{single}
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return fmt.Errorf("Unable to unmarshal schema type, error = %s", err.Error())
+	}
+	*t = Types(list)
+	return nil
+}
+
+// inputRequired are the properties MitemTiniest.Validate rejects when missing or empty
+var inputRequired = map[string][]string{
+	"MitemTiniest":       {"sourceURL", "date", "type", "licensetype", "mainimage", "headline", "body"},
+	"bodyImageTiniest":   {"source"},
+	"bodyVideoTiniest":   {"source", "videoType"},
+	"bodyGalleryTiniest": {"body"},
+}
+
+// MitemTiniest generates the schema of the mitems we accept, it follows MitemTiniest.Validate.
+// Validation profiles are not part of the schema.
+func MitemTiniest() *Schema {
+	g := &generator{required: inputRequired, nonEmpty: true, patterns: map[string]string{
+		// providers are matched case insensitively, see video.IsSupported
+		"bodyVideoTiniest.videoType": anyCase(video.Providers()),
+	}}
+	return g.document(model.MitemTiniest{}, "Mitem", "Mitem as sent by the partners' feeds")
+}
+
+// anyCase makes the pattern matching any of the values in any case.
+// The pattern language of JSON Schema has no case insensitive flag, thus every letter is given both cases, i.e. [Hh][Ll][Ss].
+func anyCase(values []string) string {
+	alternatives := make([]string, 0, len(values))
+	for _, value := range values {
+		var b strings.Builder
+		for _, r := range value {
+			upper, lower := unicode.ToUpper(r), unicode.ToLower(r)
+			if upper == lower {
+				b.WriteString(regexp.QuoteMeta(string(r)))
+				continue
+			}
+			b.WriteString("[" + string(upper) + string(lower) + "]")
+		}
+		alternatives = append(alternatives, b.String())
+	}
+	return "^(?:" + strings.Join(alternatives, "|") + ")$"
+}
+
+// TheNewMitem generates the schema of the mitems we produce, all the fields encoded unconditionally are required
+func TheNewMitem() *Schema {
+	g := &generator{output: true}
+	return g.document(model.TheNewMitem{}, "TheNewMitem", "Mitem as converted and served to the apps")
+}
+
+// BodyElement generates the schema of a single body element as accepted by MitemTiniest
+func BodyElement() *Schema {
+	s := MitemTiniest()
+	return &Schema{
+		Schema:      Draft,
+		Title:       "Body element",
+		Description: "Body element dispatched on its type field",
+		Ref:         bodyElementRef,
+		Defs:        s.Defs,
+	}
+}
+
+var documents = map[string]func() *Schema{
+	NameMitemTiniest: MitemTiniest,
+	NameTheNewMitem:  TheNewMitem,
+	NameBodyElement:  BodyElement,
+}
+
+// Names returns the names of all the documents
+func Names() []string {
+	ret := make([]string, 0, len(documents))
+	for name := range documents {
+		ret = append(ret, name)
+	}
+	sort.Strings(ret)
+	return ret
+}
+
+// Document generates the document of the given name
+func Document(name string) (*Schema, error) {
+	generate, ok := documents[name]
+	if !ok {
+		return nil, fmt.Errorf("Unknown schema %s, want one of: %s", name, strings.Join(Names(), ", "))
+	}
+	return generate(), nil
+}
@@ -0,0 +1,106 @@
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/jedynykaban/testkeyholder/model"
+	"github.com/jedynykaban/testkeyholder/video"
+)
+
+// mitemWith makes the mitem holding the body element
+func mitemWith(element string) json.RawMessage {
+	return json.RawMessage(`{
+		"sourceURL": "https://www.svt.se/nyheter/a",
+		"date": "2020-01-02T10:00:00Z",
+		"type": "article",
+		"licensetype": "editorial",
+		"mainimage": {"source": "https://www.svt.se/a.jpg"},
+		"headline": "Headline",
+		"body": [` + element + `]
+	}`)
+}
+
+// TestSchemaAgreesWithValidate checks the schema accepts and rejects the very same mitems the validation rules do
+func TestSchemaAgreesWithValidate(t *testing.T) {
+	inputs := map[string]json.RawMessage{
+		"paragraph":            mitemWith(`{"type": "paragraph", "content": "text"}`),
+		"unknown element":      mitemWith(`{"type": "quote", "whatever": 1}`),
+		"image without source": mitemWith(`{"type": "image", "caption": "c"}`),
+		"empty gallery":        mitemWith(`{"type": "gallery", "body": []}`),
+		"video without type":   mitemWith(`{"type": "video", "source": "dQw4w9WgXcQ"}`),
+		"unsupported video":    mitemWith(`{"type": "video", "source": "abc", "videoType": "realplayer"}`),
+		"missing headline":     json.RawMessage(`{"sourceURL": "https://svt.se/a", "date": "2020-01-02", "type": "article", "licensetype": "editorial", "mainimage": {"source": "a.jpg"}, "body": [{"type": "paragraph"}]}`),
+	}
+	// the shape of the source is checked by the validation rules only, thus the sources are valid ones
+	sources := map[string]string{
+		video.ProviderYoutube:     "dQw4w9WgXcQ",
+		video.ProviderVimeo:       "76979871",
+		video.ProviderDailymotion: "x7tgad0",
+		video.ProviderJWPlayer:    "abcdEFGH",
+		video.ProviderMP4:         "https://cdn.example.com/a.mp4",
+		video.ProviderHLS:         "https://cdn.example.com/a.m3u8",
+	}
+	for _, provider := range video.Providers() {
+		source, ok := sources[provider]
+		if !ok {
+			t.Errorf("No sample source of %s video", provider)
+			continue
+		}
+		for _, videoType := range []string{provider, strings.ToUpper(provider), strings.Title(provider)} {
+			inputs["video "+videoType] = mitemWith(fmt.Sprintf(`{"type": "video", "source": %q, "videoType": %q}`, source, videoType))
+		}
+	}
+
+	s := MitemTiniest()
+	for name, input := range inputs {
+		var mt model.MitemTiniest
+		if err := json.Unmarshal(input, &mt); err != nil {
+			t.Fatalf("%s: unable to unmarshal mitem, error = %v", name, err)
+		}
+		validateErrs := mt.ValidateWith(nil, nil, input)
+		schemaErrs := s.Validate(input)
+		if model.HasErrors(validateErrs) != (len(schemaErrs) > 0) {
+			t.Errorf("%s: Validate errors = %v, schema errors = %v", name, validateErrs, schemaErrs)
+		}
+	}
+}
+
+func TestVideoTypePattern(t *testing.T) {
+	pattern := anyCase([]string{"hls", "mp4"})
+	if want := "^(?:[Hh][Ll][Ss]|[Mm][Pp]4)$"; pattern != want {
+		t.Errorf("anyCase got = %s, want = %s", pattern, want)
+	}
+	doc, err := json.Marshal(MitemTiniest())
+	if err != nil {
+		t.Fatalf("Unable to marshal schema, error = %v", err)
+	}
+	if !strings.Contains(string(doc), `"pattern":`) || strings.Contains(string(doc), `"enum":["dailymotion"`) {
+		t.Errorf("videoType is not described by a pattern in %s", doc)
+	}
+}
+
+func TestDocuments(t *testing.T) {
+	for _, name := range Names() {
+		s, err := Document(name)
+		if err != nil {
+			t.Fatalf("Document(%s) unexpected error = %v", name, err)
+		}
+		data, err := json.Marshal(s)
+		if err != nil {
+			t.Fatalf("Document(%s) unable to marshal, error = %v", name, err)
+		}
+		var decoded Schema
+		if err := json.Unmarshal(data, &decoded); err != nil {
+			t.Errorf("Document(%s) unable to unmarshal, error = %v", name, err)
+		}
+		if decoded.Schema != Draft {
+			t.Errorf("Document(%s) $schema got = %s, want = %s", name, decoded.Schema, Draft)
+		}
+	}
+	if _, err := Document("nope"); err == nil {
+		t.Errorf("Document of unknown name expected error")
+	}
+}
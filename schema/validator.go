@@ -0,0 +1,242 @@
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/jedynykaban/testkeyholder/model"
+)
+
+// Validate checks the raw mitem against the schema.
+// Only the keywords the generator makes use of are checked, additional properties are allowed.
+// All returned errors are of *model.ValidationError type.
+func (s *Schema) Validate(data json.RawMessage) []error {
+	if len(bytes.TrimSpace(data)) == 0 {
+		return []error{model.NewValidationError("", model.CodeEmpty, nil, "An empty mitem passed in")}
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var doc interface{}
+	if err := dec.Decode(&doc); err != nil {
+		return []error{model.NewValidationError("", model.CodeMalformed, nil, "Unable to unmarshal passed mitem")}
+	}
+	v := &validator{root: s, patterns: map[string]*regexp.Regexp{}}
+	var ret []error
+	for _, ve := range v.validate(s, doc, "") {
+		ret = append(ret, ve)
+	}
+	return ret
+}
+
+type validator struct {
+	root *Schema
+	// patterns caches the compiled patterns
+	patterns map[string]*regexp.Regexp
+}
+
+func (v *validator) validate(s *Schema, value interface{}, path string) []*model.ValidationError {
+	if len(s.Ref) > 0 {
+		resolved, err := v.resolve(s.Ref)
+		if err != nil {
+			return []*model.ValidationError{model.NewValidationError(path, model.CodeMalformed, nil, err.Error())}
+		}
+		return v.validate(resolved, value, path)
+	}
+	if len(s.Type) > 0 {
+		if actual := typeOf(value); !s.Type.has(actual) && !(actual == "integer" && s.Type.has("number")) {
+			msg := fmt.Sprintf("Field %s must be %s, got %s", fieldName(path), strings.Join(s.Type, " or "), actual)
+			return []*model.ValidationError{model.NewValidationError(path, model.CodeInvalidFormat, value, msg)}
+		}
+	}
+	var ret []*model.ValidationError
+	if s.Const != nil && !equal(s.Const, value) {
+		msg := fmt.Sprintf("Unsupported %s got = %v, want = %v", fieldName(path), value, s.Const)
+		ret = append(ret, model.NewValidationError(path, model.CodeUnsupportedValue, value, msg))
+	}
+	if len(s.Enum) > 0 && !inEnum(s.Enum, value) {
+		msg := fmt.Sprintf("Unsupported %s got = %v, want one of = %v", fieldName(path), value, s.Enum)
+		ret = append(ret, model.NewValidationError(path, model.CodeUnsupportedValue, value, msg))
+	}
+	switch val := value.(type) {
+	case string:
+		if utf8.RuneCountInString(val) < s.MinLength {
+			ret = append(ret, model.NewValidationError(path, model.CodeRequired, nil, fmt.Sprintf("Mandatory field %s is empty", fieldName(path))))
+		} else if len(s.Pattern) > 0 {
+			ret = append(ret, v.validatePattern(s.Pattern, val, path)...)
+		}
+	case []interface{}:
+		if len(val) < s.MinItems {
+			ret = append(ret, model.NewValidationError(path, model.CodeRequired, nil, fmt.Sprintf("Mandatory field %s is empty", fieldName(path))))
+		}
+		if s.Items != nil {
+			for idx, item := range val {
+				ret = append(ret, v.validate(s.Items, item, model.JSONPointer(path, idx))...)
+			}
+		}
+	case map[string]interface{}:
+		for _, name := range s.Required {
+			if _, ok := val[name]; !ok {
+				ret = append(ret, model.NewValidationError(model.JSONPointer(path, name), model.CodeRequired, nil,
+					fmt.Sprintf("Mandatory field %s is missing", fieldName(model.JSONPointer(path, name)))))
+			}
+		}
+		names := make([]string, 0, len(val))
+		for name := range val {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			property := val[name]
+			if ps, ok := s.Properties[name]; ok {
+				ret = append(ret, v.validate(ps, property, model.JSONPointer(path, name))...)
+			} else if s.AdditionalProperties != nil {
+				ret = append(ret, v.validate(s.AdditionalProperties, property, model.JSONPointer(path, name))...)
+			}
+		}
+	}
+	// the alternatives are checked only when the value is fine otherwise,
+	// the problems already found would be reported once again by the matching alternative
+	if len(ret) > 0 {
+		return ret
+	}
+	if len(s.OneOf) > 0 {
+		ret = append(ret, v.validateOneOf(s.OneOf, value, path)...)
+	}
+	if s.Not != nil && len(v.validate(s.Not, value, path)) == 0 {
+		msg := fmt.Sprintf("Unsupported %s got = %v", fieldName(path), value)
+		ret = append(ret, model.NewValidationError(path, model.CodeUnsupportedValue, value, msg))
+	}
+	return ret
+}
+
+// validatePattern checks the string against the pattern, the patterns are RE2 compatible subset of ECMA 262
+func (v *validator) validatePattern(pattern, value, path string) []*model.ValidationError {
+	re, ok := v.patterns[pattern]
+	if !ok {
+		var err error
+		if re, err = regexp.Compile(pattern); err != nil {
+			msg := fmt.Sprintf("Invalid pattern %s of %s, error = %s", pattern, fieldName(path), err.Error())
+			return []*model.ValidationError{model.NewValidationError(path, model.CodeMalformed, nil, msg)}
+		}
+		v.patterns[pattern] = re
+	}
+	if re.MatchString(value) {
+		return nil
+	}
+	msg := fmt.Sprintf("Unsupported %s got = %s, want to match = %s", fieldName(path), value, pattern)
+	return []*model.ValidationError{model.NewValidationError(path, model.CodeUnsupportedValue, value, msg)}
+}
+
+// validateOneOf requires exactly one alternative to match. When none does, the problems
+// of the alternative selected by a discriminator (a property holding its const value, the body element's type) are reported.
+func (v *validator) validateOneOf(alternatives []*Schema, value interface{}, path string) []*model.ValidationError {
+	var matched int
+	var discriminated [][]*model.ValidationError
+	for _, alternative := range alternatives {
+		errs := v.validate(alternative, value, path)
+		if len(errs) == 0 {
+			matched++
+			continue
+		}
+		if v.discriminates(alternative, value) {
+			discriminated = append(discriminated, errs)
+		}
+	}
+	switch {
+	case matched == 1:
+		return nil
+	case matched > 1:
+		msg := fmt.Sprintf("Field %s matches more than one of the alternatives", fieldName(path))
+		return []*model.ValidationError{model.NewValidationError(path, model.CodeUnsupportedValue, nil, msg)}
+	case len(discriminated) == 1:
+		return discriminated[0]
+	}
+	msg := fmt.Sprintf("Field %s matches none of the alternatives", fieldName(path))
+	return []*model.ValidationError{model.NewValidationError(path, model.CodeUnsupportedValue, nil, msg)}
+}
+
+// discriminates tells whether the object holds the const value of one of the alternative's properties
+func (v *validator) discriminates(s *Schema, value interface{}) bool {
+	if len(s.Ref) > 0 {
+		resolved, err := v.resolve(s.Ref)
+		if err != nil {
+			return false
+		}
+		s = resolved
+	}
+	object, ok := value.(map[string]interface{})
+	if !ok {
+		return false
+	}
+	for name, property := range s.Properties {
+		if property.Const != nil && equal(property.Const, object[name]) {
+			return true
+		}
+	}
+	return false
+}
+
+// resolve finds the local definition, only #/$defs/name references are supported
+func (v *validator) resolve(ref string) (*Schema, error) {
+	name := strings.TrimPrefix(ref, "#/$defs/")
+	if def, ok := v.root.Defs[name]; ok && name != ref {
+		return def, nil
+	}
+	return nil, fmt.Errorf("Unable to resolve schema reference %s", ref)
+}
+
+func typeOf(value interface{}) string {
+	switch val := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case json.Number:
+		if _, err := val.Int64(); err == nil {
+			return "integer"
+		}
+		return "number"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+// equal compares the values by their JSON encoding, numbers decoded as json.Number included
+func equal(a, b interface{}) bool {
+	ea, err := json.Marshal(a)
+	if err != nil {
+		return false
+	}
+	eb, err := json.Marshal(b)
+	if err != nil {
+		return false
+	}
+	return bytes.Equal(ea, eb)
+}
+
+func inEnum(enum []interface{}, value interface{}) bool {
+	for _, e := range enum {
+		if equal(e, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// fieldName makes the dotted field name out of the JSON pointer i.e. mainimage.source
+func fieldName(pointer string) string {
+	if len(pointer) == 0 {
+		return "mitem"
+	}
+	return strings.Replace(strings.TrimPrefix(pointer, "/"), "/", ".", -1)
+}
@@ -0,0 +1,115 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/jedynykaban/testkeyholder/model"
+	"github.com/jedynykaban/testkeyholder/services"
+)
+
+// validateResponse is the body of the /validate response
+type validateResponse struct {
+	Valid  bool                     `json:"valid"`
+	Errors []*model.ValidationError `json:"errors,omitempty"`
+}
+
+// extractResponse is the body of the /extract/{field} response
+type extractResponse struct {
+	Field string      `json:"field"`
+	Value interface{} `json:"value"`
+}
+
+// handleValidate responds with 200 for a valid mitem, 400 for an empty or malformed document
+// and 422 for an invalid one (a malformed part of the mitem included), the body lists the problems in all the cases
+func (s *Server) handleValidate(w http.ResponseWriter, r *http.Request, data json.RawMessage) {
+	errs := s.kojo.Validate(data)
+	res := validateResponse{Valid: !model.HasErrors(errs)}
+	status := http.StatusOK
+	if !res.Valid {
+		status = http.StatusUnprocessableEntity
+	}
+	for _, err := range errs {
+		ve := model.AsValidationError(err)
+		if ve.Path == "" && (ve.Code == model.CodeEmpty || ve.Code == model.CodeMalformed) {
+			status = http.StatusBadRequest
+		}
+		res.Errors = append(res.Errors, ve)
+	}
+	writeJSON(w, status, res)
+}
+
+// handleExtract responds with 404 for an unknown field, 400 for a malformed mitem
+// and 422 when the field cannot be extracted
+func (s *Server) handleExtract(w http.ResponseWriter, r *http.Request, data json.RawMessage) {
+	field := strings.TrimPrefix(r.URL.Path, "/extract/")
+	if len(field) == 0 || strings.Contains(field, "/") {
+		writeError(w, http.StatusNotFound, "Unknown field "+field+", want one of: "+strings.Join(services.FieldNames(), ", "))
+		return
+	}
+	pm, err := s.kojo.Parse(data)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Unable to unmarshal passed mitem: "+err.Error())
+		return
+	}
+	value, err := pm.Field(field)
+	if err == services.ErrUnknownField {
+		writeError(w, http.StatusNotFound, "Unknown field "+field+", want one of: "+strings.Join(services.FieldNames(), ", "))
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, extractResponse{Field: field, Value: value})
+}
+
+// handleConvert responds with 400 for a malformed mitem and 422 when it cannot be converted
+func (s *Server) handleConvert(w http.ResponseWriter, r *http.Request, data json.RawMessage) {
+	if !json.Valid(data) {
+		writeError(w, http.StatusBadRequest, "Unable to unmarshal passed mitem")
+		return
+	}
+	mitem, err := s.kojo.ConvertMitem(data)
+	if err != nil {
+		writeError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, mitem)
+}
+
+// handleProcess responds with 400 for a malformed mitem and 422 when a processing step fails
+func (s *Server) handleProcess(w http.ResponseWriter, r *http.Request, data json.RawMessage) {
+	if !json.Valid(data) {
+		writeError(w, http.StatusBadRequest, "Unable to unmarshal passed mitem")
+		return
+	}
+	res, err := s.kojo.ProcessFor(r.URL.Query().Get("publisher"), data)
+	if err != nil {
+		writeError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, res)
+}
+
+// handleRender responds with 400 for a malformed mitem and 422 when it cannot be converted or rendered
+func (s *Server) handleRender(w http.ResponseWriter, r *http.Request, data json.RawMessage) {
+	if !json.Valid(data) {
+		writeError(w, http.StatusBadRequest, "Unable to unmarshal passed mitem")
+		return
+	}
+	mitem, err := s.kojo.ConvertMitem(data)
+	if err != nil {
+		writeError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+	var out bytes.Buffer
+	if err := s.renderer.RenderMitem(&out, mitem); err != nil {
+		writeError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	out.WriteTo(w)
+}
@@ -0,0 +1,143 @@
+// Package server exposes Kojo over HTTP, so services not written in Go
+// can use the very same validation and extraction rules.
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+
+	"github.com/jedynykaban/testkeyholder/render"
+	"github.com/jedynykaban/testkeyholder/services"
+)
+
+// MaxBodySize is the largest mitem accepted by the server
+const MaxBodySize = 10 << 20
+
+// Server handles the HTTP API:
+//
+//	POST /validate          validates the mitem
+//	POST /extract/{field}   extracts the field, see services.FieldNames
+//	POST /convert           converts the mitem into TheNewMitem
+//	POST /process           runs the processing pipeline, ?publisher= selects the publisher
+//	POST /render            converts the mitem and renders it to HTML
+//	GET  /healthz           liveness probe
+//	GET  /readyz            readiness probe
+type Server struct {
+	kojo     services.Kojo
+	renderer *render.Renderer
+	ready    int32
+	mux      *http.ServeMux
+}
+
+// New - ctor like function - creates the server, it is not ready until SetReady(true) is called
+func New(kojo services.Kojo) (*Server, error) {
+	renderer, err := render.New()
+	if err != nil {
+		return nil, fmt.Errorf("Unable to set up the default renderer, error = %s", err.Error())
+	}
+	s := &Server{kojo: kojo, renderer: renderer, mux: http.NewServeMux()}
+	s.mux.HandleFunc("/validate", s.post(s.handleValidate))
+	s.mux.HandleFunc("/extract/", s.post(s.handleExtract))
+	s.mux.HandleFunc("/convert", s.post(s.handleConvert))
+	s.mux.HandleFunc("/process", s.post(s.handleProcess))
+	s.mux.HandleFunc("/render", s.post(s.handleRender))
+	s.mux.HandleFunc("/healthz", s.handleHealth)
+	s.mux.HandleFunc("/readyz", s.handleReady)
+	return s, nil
+}
+
+// SetRenderer replaces the renderer used by /render i.e. with one using custom templates
+func (s *Server) SetRenderer(r *render.Renderer) {
+	s.renderer = r
+}
+
+// SetReady changes the readiness reported by /readyz
+func (s *Server) SetReady(ready bool) {
+	var v int32
+	if ready {
+		v = 1
+	}
+	atomic.StoreInt32(&s.ready, v)
+}
+
+// ServeHTTP implements http.Handler
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+	s.mux.ServeHTTP(rec, r)
+	log.WithFields(log.Fields{
+		"method":   r.Method,
+		"path":     r.URL.Path,
+		"status":   rec.status,
+		"duration": time.Since(start).String(),
+	}).Debug("Request handled")
+}
+
+// statusRecorder remembers the status code for logging
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (sr *statusRecorder) WriteHeader(status int) {
+	sr.status = status
+	sr.ResponseWriter.WriteHeader(status)
+}
+
+// errorResponse is the body of all the error responses
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.WithFields(log.Fields{"error": err}).Error("Unable to write response")
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, errorResponse{Error: message})
+}
+
+// post allows POST requests only and reads the mitem from the request body
+func (s *Server) post(handle func(w http.ResponseWriter, r *http.Request, data json.RawMessage)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+			return
+		}
+		data, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, MaxBodySize))
+		if err != nil {
+			var mbe *http.MaxBytesError
+			if errors.As(err, &mbe) {
+				writeError(w, http.StatusRequestEntityTooLarge, "Mitem is too large")
+				return
+			}
+			writeError(w, http.StatusBadRequest, "Unable to read request body: "+err.Error())
+			return
+		}
+		handle(w, r, data)
+	}
+}
+
+func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+func (s *Server) handleReady(w http.ResponseWriter, r *http.Request) {
+	if atomic.LoadInt32(&s.ready) == 0 {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]string{"status": "not ready"})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ready"})
+}
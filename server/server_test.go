@@ -0,0 +1,63 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jedynykaban/testkeyholder/services"
+)
+
+const validMitem = `{
+	"sourceURL": "https://www.svt.se/nyheter/a",
+	"date": "2020-01-02T10:00:00Z",
+	"type": "article",
+	"licensetype": "editorial",
+	"mainimage": {"source": "https://www.svt.se/a.jpg"},
+	"headline": "Headline",
+	"body": [{"type": "paragraph", "content": "text"}]
+}`
+
+func TestStatusCodes(t *testing.T) {
+	srv, err := New(services.NewKojo())
+	if err != nil {
+		t.Fatalf("New error = %v", err)
+	}
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		want   int
+	}{
+		{"valid mitem", http.MethodPost, "/validate", validMitem, http.StatusOK},
+		{"empty mitem", http.MethodPost, "/validate", "", http.StatusBadRequest},
+		{"malformed mitem", http.MethodPost, "/validate", `{"sourceURL": `, http.StatusBadRequest},
+		{"malformed body element", http.MethodPost, "/validate", strings.Replace(validMitem, `"content": "text"}]`, `"content": "text"}, 7]`, 1), http.StatusUnprocessableEntity},
+		{"too large mitem", http.MethodPost, "/validate", strings.Repeat(" ", MaxBodySize+1), http.StatusRequestEntityTooLarge},
+		{"invalid mitem", http.MethodPost, "/validate", `{"sourceURL": "https://www.svt.se/a"}`, http.StatusUnprocessableEntity},
+		{"validate with GET", http.MethodGet, "/validate", "", http.StatusMethodNotAllowed},
+		{"extract field", http.MethodPost, "/extract/sourceURL", validMitem, http.StatusOK},
+		{"extract unknown field", http.MethodPost, "/extract/nope", validMitem, http.StatusNotFound},
+		{"extract from malformed mitem", http.MethodPost, "/extract/sourceURL", `[`, http.StatusBadRequest},
+		{"convert", http.MethodPost, "/convert", validMitem, http.StatusOK},
+		{"convert malformed mitem", http.MethodPost, "/convert", `[`, http.StatusBadRequest},
+		{"health", http.MethodGet, "/healthz", "", http.StatusOK},
+		{"not ready", http.MethodGet, "/readyz", "", http.StatusServiceUnavailable},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		srv.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body)))
+		if rec.Code != tt.want {
+			t.Errorf("%s: %s %s got status = %d, want = %d, body = %s", tt.name, tt.method, tt.path, rec.Code, tt.want, rec.Body)
+		}
+	}
+
+	srv.SetReady(true)
+	rec := httptest.NewRecorder()
+	srv.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("GET /readyz when ready got status = %d, want = %d", rec.Code, http.StatusOK)
+	}
+}
@@ -0,0 +1,103 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/jedynykaban/testkeyholder/model"
+	"github.com/jedynykaban/testkeyholder/slug"
+)
+
+// bylinePrefixes are stripped from the beginning of a byline
+var bylinePrefixes = regexp.MustCompile(`(?i)^\s*((text|foto|photo)(\s*(och|and|&)\s*(text|foto|photo))?\s*:|by\s|av\s|von\s|af\s)\s*`)
+
+// bylineSeparators split a byline into names, conjunctions cover
+// Swedish, English, German, Danish/Norwegian and Finnish bylines
+var bylineSeparators = regexp.MustCompile(`(?i)\s*(,|;|&|\s(och|and|und|og|ja)\s)\s*`)
+
+var whitespace = regexp.MustCompile(`\s+`)
+
+// SplitByline splits a byline like "Av Anna Svensson och Erik Berg" into names
+func SplitByline(byline string) []string {
+	byline = bylinePrefixes.ReplaceAllString(byline, "")
+	var ret []string
+	for _, name := range bylineSeparators.Split(byline, -1) {
+		name = whitespace.ReplaceAllString(strings.TrimSpace(name), " ")
+		if len(name) > 0 {
+			ret = append(ret, name)
+		}
+	}
+	return ret
+}
+
+// authorsCollector builds de-duplicated list of authors
+type authorsCollector struct {
+	authors  []model.Author
+	seen     map[string]bool
+	warnings []error
+}
+
+func (ac *authorsCollector) add(name, playlistName string) {
+	key := strings.ToLower(name)
+	if ac.seen[key] {
+		return
+	}
+	ac.seen[key] = true
+	if len(playlistName) == 0 {
+		playlistName = slug.Make(name)
+	}
+	ac.authors = append(ac.authors, model.Author{Name: name, PlaylistName: playlistName})
+}
+
+func (ac *authorsCollector) warn(path string, value interface{}, message string) {
+	e := model.NewValidationError(path, model.CodeInvalidFormat, value, message)
+	e.Severity = model.SeverityWarning
+	ac.warnings = append(ac.warnings, e)
+}
+
+// collect adds authors from a single entry of the authors field:
+// a byline string or an object with name and optional playlistName
+func (ac *authorsCollector) collect(path string, entry interface{}) {
+	switch a := entry.(type) {
+	case string:
+		for _, name := range SplitByline(a) {
+			ac.add(name, "")
+		}
+	case map[string]interface{}:
+		name, _ := a["name"].(string)
+		name = whitespace.ReplaceAllString(strings.TrimSpace(name), " ")
+		if len(name) == 0 {
+			ac.warn(path+"/name", nil, "Author has no name, skipped")
+			return
+		}
+		playlistName, _ := a["playlistName"].(string)
+		ac.add(name, strings.TrimSpace(playlistName))
+	case nil:
+	default:
+		ac.warn(path, a, fmt.Sprintf("Unsupported author value of type %T, skipped", a))
+	}
+}
+
+// parseAuthors accepts the authors given as a byline, a single author object
+// or a list of bylines and author objects. Authors that cannot be read are
+// skipped and reported as warnings.
+func parseAuthors(data json.RawMessage) ([]model.Author, []error, error) {
+	if len(data) == 0 {
+		return nil, nil, nil
+	}
+	var authors interface{}
+	if err := json.Unmarshal(data, &authors); err != nil {
+		return nil, nil, fmt.Errorf("Unable to unmarshal authors, error = %s", err.Error())
+	}
+	ac := &authorsCollector{seen: make(map[string]bool)}
+	if list, ok := authors.([]interface{}); ok {
+		for idx, entry := range list {
+			ac.collect(model.JSONPointer("/authors", idx), entry)
+		}
+	} else {
+		ac.collect("/authors", authors)
+	}
+	return ac.authors, ac.warnings, nil
+}
@@ -0,0 +1,67 @@
+package services
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/jedynykaban/testkeyholder/model"
+)
+
+func TestSplitByline(t *testing.T) {
+	tests := []struct {
+		byline string
+		want   []string
+	}{
+		{"Av Anna Svensson och Erik Berg", []string{"Anna Svensson", "Erik Berg"}},
+		{"By John Smith and Jane Doe", []string{"John Smith", "Jane Doe"}},
+		{"Von Hans Müller und Eva Schmidt", []string{"Hans Müller", "Eva Schmidt"}},
+		{"Af Lars Hansen og Mette Jensen", []string{"Lars Hansen", "Mette Jensen"}},
+		{"Matti Virtanen ja Liisa Korhonen", []string{"Matti Virtanen", "Liisa Korhonen"}},
+		{"Text och foto: Anna Svensson", []string{"Anna Svensson"}},
+		{"Anna, Bo;  Cecilia   Ek & David", []string{"Anna", "Bo", "Cecilia Ek", "David"}},
+		{"Johanna Ögren", []string{"Johanna Ögren"}},
+		{"Oscar Andersson", []string{"Oscar Andersson"}},
+		{"  ", nil},
+	}
+	for _, tt := range tests {
+		if got := SplitByline(tt.byline); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("SplitByline(%q) got = %q, want = %q", tt.byline, got, tt.want)
+		}
+	}
+}
+
+func TestParseAuthors(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		want     []model.Author
+		warnings int
+		wantErr  bool
+	}{
+		{"missing", ``, nil, 0, false},
+		{"byline", `"Anna Svensson och Erik Berg"`, []model.Author{{Name: "Anna Svensson", PlaylistName: "anna-svensson"}, {Name: "Erik Berg", PlaylistName: "erik-berg"}}, 0, false},
+		{"object", `{"name": " Bo  Berg ", "playlistName": "bo"}`, []model.Author{{Name: "Bo Berg", PlaylistName: "bo"}}, 0, false},
+		{"duplicates", `["Anna Svensson", {"name": "anna svensson"}]`, []model.Author{{Name: "Anna Svensson", PlaylistName: "anna-svensson"}}, 0, false},
+		{"unreadable entries", `["Anna Svensson", {"playlistName": "x"}, 42, null]`, []model.Author{{Name: "Anna Svensson", PlaylistName: "anna-svensson"}}, 2, false},
+		{"malformed", `[`, nil, 0, true},
+	}
+	for _, tt := range tests {
+		got, warnings, err := parseAuthors(json.RawMessage(tt.data))
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: parseAuthors error = %v, wantErr = %t", tt.name, err, tt.wantErr)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: parseAuthors got = %+v, want = %+v", tt.name, got, tt.want)
+		}
+		if len(warnings) != tt.warnings {
+			t.Errorf("%s: parseAuthors warnings = %v, want %d", tt.name, warnings, tt.warnings)
+		}
+		for _, w := range warnings {
+			if model.HasErrors([]error{w}) {
+				t.Errorf("%s: parseAuthors warning %v is reported as error", tt.name, w)
+			}
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/jedynykaban/testkeyholder/model"
+	"github.com/jedynykaban/testkeyholder/sanitize"
+	"github.com/jedynykaban/testkeyholder/slug"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+// ConvertMitem: converts raw mitem data into the canonical TheNewMitem structure, authors included.
+// The mitem is not processed (see Process), the body is sanitised by ConvertMitemTiniest.
+func (ks *kojoService) ConvertMitem(data json.RawMessage) (*model.TheNewMitem, error) {
+	pm, err := ks.Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to unmarshal passed mitem to mitemTines, error = %s", err.Error())
+	}
+	ret, err := ks.ConvertMitemTiniest(pm.Tiniest())
+	if err != nil {
+		return nil, err
+	}
+	authors, warnings, err := pm.Authors()
+	if err != nil {
+		return nil, err
+	}
+	for _, warning := range warnings {
+		log.WithFields(log.Fields{"warning": warning}).Warn("Author skipped while converting the mitem")
+	}
+	ret.Meta.Authors = authors
+	return ret, nil
+}
+
+// ConvertMitemTiniest: converts MitemTiniest into the canonical TheNewMitem structure.
+// MitemTiniest does not carry authors, thus Meta.Authors is left empty.
+// The slug is made out of the headline, it is not checked for uniqueness.
+// The HTML of the body is sanitised (see sanitize.DefaultPolicy) and a missing main image
+// is replaced with the best image of the body, see SelectMainImageStep.
+// Word count, reading time and excerpt are derived from the body with model.DefaultTextOptions.
+func (ks *kojoService) ConvertMitemTiniest(mt *model.MitemTiniest) (*model.TheNewMitem, error) {
+	creationDate, err := ks.ConvertCreationDate(mt)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to convert creation date (%s), error = %s", mt.Date, err.Error())
+	}
+	ret := &model.TheNewMitem{
+		Type:     mt.Type,
+		Headline: mt.Headline,
+		Slug:     slug.Truncate(slug.Make(mt.Headline), slug.DefaultMaxLength),
+		MainImage: model.Image{
+			Source:  mt.MainImage.Source,
+			Caption: mt.MainImage.Caption,
+			Height:  mt.MainImage.Height,
+			Width:   mt.MainImage.Width,
+		},
+		CreationDate: creationDate,
+		Status:       mt.Status,
+		Body:         model.Body(mt.Body),
+		Meta: model.Meta{
+			SourceURL:         mt.SourceURL,
+			LogoURL:           mt.Meta.LogoURL,
+			MosaiqPrimary:     model.MosaiqPrimary{Set: mt.Meta.MosaiqPrimary, Domain: primaryDomain(ks.urls, mt)},
+			UserEdited:        mt.Meta.UserEdited,
+			MainImageFallback: mt.Meta.MainImageFallback,
+			Section: model.Section{
+				Tier1: mt.Category.Tier1,
+				Tier2: mt.Category.Tier2,
+			},
+			AdsPolicy: model.AdsPolicy{
+				On:     mt.AdsPolicy.On,
+				MaxAds: mt.AdsPolicy.MaxAds,
+			},
+			Tags: mt.Meta.Tags,
+		},
+	}
+	body, err := model.DecodeBody(mt.Body)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to decode body, error = %s", err.Error())
+	}
+	if changes := sanitiseBody(sanitize.DefaultPolicy, body); len(changes) > 0 {
+		if ret.Body, err = model.EncodeBody(body); err != nil {
+			return nil, err
+		}
+		log.WithFields(log.Fields{"changes": changes}).Debug("Body sanitised while converting the mitem")
+	}
+	if len(strings.TrimSpace(ret.MainImage.Source)) == 0 {
+		if best, _, ok := bestBodyImage(body, mt.Meta.LogoURL); ok {
+			ret.MainImage = model.Image{Source: best.Source, Caption: best.Caption, Height: best.Height, Width: best.Width}
+			ret.Meta.MainImageFallback = true
+		}
+	}
+	stats := model.AnalyzeText(mt.Headline, body, model.DefaultTextOptions)
+	ret.Meta.WordCount = stats.WordCount
+	ret.Meta.ReadingTime = stats.ReadingMinutes
+	ret.Meta.Excerpt = stats.Excerpt
+	return ret, nil
+}
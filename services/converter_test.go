@@ -0,0 +1,70 @@
+package services
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/jedynykaban/testkeyholder/model"
+)
+
+func TestConvertMitem(t *testing.T) {
+	input := json.RawMessage(`{
+		"sourceURL": "https://www.svt.se/nyheter/a?utm_source=fb",
+		"date": "2020-01-02T10:00:00+01:00",
+		"type": "article",
+		"licensetype": "editorial",
+		"mainimage": {"source": "https://www.svt.se/a.jpg", "caption": "Caption", "width": 1280, "height": 720},
+		"headline": "Räksmörgås på Åland",
+		"category": {"tier1": "news", "tier2": "sweden"},
+		"adspolicy": {"on": true, "maxAds": 3},
+		"meta": {"logoURL": "https://www.svt.se/logo.png", "userEdited": true, "tags": [{"name": "food"}]},
+		"status": 2,
+		"authors": ["Anna Andersson", {"name": "Bo Berg", "playlistName": "bo"}],
+		"body": [{"type": "paragraph", "content": "One two three four five."}]
+	}`)
+	got, err := NewKojo().ConvertMitem(input)
+	if err != nil {
+		t.Fatalf("ConvertMitem unexpected error = %v", err)
+	}
+
+	checks := []struct {
+		name      string
+		got, want interface{}
+	}{
+		{"type", got.Type, "article"},
+		{"headline", got.Headline, "Räksmörgås på Åland"},
+		{"slug", got.Slug, "raksmorgas-pa-aland"},
+		{"mainImage", got.MainImage, model.Image{Source: "https://www.svt.se/a.jpg", Caption: "Caption", Width: 1280, Height: 720}},
+		{"creationDate", got.CreationDate.Equal(time.Date(2020, 1, 2, 9, 0, 0, 0, time.UTC)), true},
+		{"status", got.Status, 2},
+		{"body", len(got.Body), 1},
+		{"meta.sourceURL", got.Meta.SourceURL, "https://www.svt.se/nyheter/a?utm_source=fb"},
+		{"meta.logoURL", got.Meta.LogoURL, "https://www.svt.se/logo.png"},
+		{"meta.userEdited", got.Meta.UserEdited, true},
+		{"meta.section", got.Meta.Section.Tier1 + "/" + got.Meta.Section.Tier2, "news/sweden"},
+		{"meta.adsPolicy", got.Meta.AdsPolicy, model.AdsPolicy{On: true, MaxAds: 3}},
+		{"meta.tags", len(got.Meta.Tags), 1},
+		{"meta.authors", len(got.Meta.Authors), 2},
+		{"meta.wordCount", got.Meta.WordCount > 0, true},
+		{"meta.mainImageFallback", got.Meta.MainImageFallback, false},
+	}
+	for _, c := range checks {
+		if c.got != c.want {
+			t.Errorf("%s got = %v, want = %v", c.name, c.got, c.want)
+		}
+	}
+	if len(got.Meta.Authors) == 2 && got.Meta.Authors[1] != (model.Author{Name: "Bo Berg", PlaylistName: "bo"}) {
+		t.Errorf("meta.authors[1] got = %+v", got.Meta.Authors[1])
+	}
+}
+
+func TestConvertMitemInvalidDate(t *testing.T) {
+	input := json.RawMessage(`{"sourceURL": "https://www.svt.se/a", "date": "not a date", "headline": "h"}`)
+	if _, err := NewKojo().ConvertMitem(input); err == nil {
+		t.Errorf("ConvertMitem of mitem with invalid date expected error")
+	}
+	if _, err := NewKojo().ConvertMitem(json.RawMessage(`[`)); err == nil {
+		t.Errorf("ConvertMitem of malformed mitem expected error")
+	}
+}
@@ -0,0 +1,203 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/jedynykaban/testkeyholder/images"
+	"github.com/jedynykaban/testkeyholder/model"
+)
+
+// Names of the image processing steps
+const (
+	StepNormaliseImages = "normalise-images"
+	StepSelectMainImage = "select-main-image"
+)
+
+// NormaliseImagesStep resolves the sources of all the images (mainimage and gallery ones included)
+// against the mitem's sourceURL and upgrades them to https when allowed by the options.
+// Missing dimensions are probed with the options' fetcher, images below the minimum size are reported.
+// Images that cannot be resolved or probed are left untouched and reported, it is up to the validation to reject them.
+func NormaliseImagesStep(opts images.Options) ProcessStep {
+	return ProcessStep{
+		Name:  StepNormaliseImages,
+		Order: 50,
+		Func: func(data json.RawMessage) (json.RawMessage, []string, error) {
+			var mt model.MitemTiniest
+			if err := json.Unmarshal(data, &mt); err != nil {
+				return nil, nil, fmt.Errorf("Unable to unmarshal passed mitem, error = %s", err.Error())
+			}
+			body, err := model.DecodeBody(mt.Body)
+			if err != nil {
+				return nil, nil, err
+			}
+			in := &imageNormaliser{opts: opts, sourceURL: mt.SourceURL, probed: map[string]images.Dimensions{}}
+			var bodyChanged bool
+			model.WalkBody(body, func(path string, element model.BodyElement) {
+				e, ok := element.(*model.ImageElement)
+				if !ok {
+					return
+				}
+				if in.normalise(path, &e.Source, &e.Width, &e.Height) {
+					bodyChanged = true
+				}
+			})
+			mainImage := model.Image{Source: mt.MainImage.Source, Width: mt.MainImage.Width, Height: mt.MainImage.Height}
+			mainImageChanged := len(mainImage.Source) > 0 && in.normalise("/mainimage", &mainImage.Source, &mainImage.Width, &mainImage.Height)
+
+			processed := data
+			if bodyChanged {
+				encoded, err := model.EncodeBody(body)
+				if err != nil {
+					return nil, nil, err
+				}
+				if processed, err = setField(processed, "body", encoded); err != nil {
+					return nil, nil, err
+				}
+			}
+			if mainImageChanged {
+				fields := map[string]interface{}{"source": mainImage.Source, "width": mainImage.Width, "height": mainImage.Height}
+				if processed, err = setObjectFields(processed, "mainimage", fields); err != nil {
+					return nil, nil, err
+				}
+			}
+			return processed, in.changes, nil
+		},
+	}
+}
+
+// imageNormaliser normalises the images of a single mitem, every source is probed at most once
+type imageNormaliser struct {
+	opts      images.Options
+	sourceURL string
+	probed    map[string]images.Dimensions
+	changes   []string
+}
+
+// normalise fixes the image in place and tells whether it has been changed
+func (in *imageNormaliser) normalise(path string, source *string, width, height *int) bool {
+	var changed bool
+	resolved, err := in.opts.ResolveSource(*source, in.sourceURL)
+	if err != nil {
+		in.changes = append(in.changes, fmt.Sprintf("%s: left untouched, %s", path, err.Error()))
+		return false
+	}
+	if resolved != *source {
+		in.changes = append(in.changes, fmt.Sprintf("%s: image source %s resolved to %s", path, *source, resolved))
+		*source = resolved
+		changed = true
+	}
+	if (*width <= 0 || *height <= 0) && in.opts.Fetcher != nil {
+		d, err := in.probe(resolved)
+		if err != nil {
+			in.changes = append(in.changes, fmt.Sprintf("%s: unable to probe image dimensions, %s", path, err.Error()))
+		} else {
+			in.changes = append(in.changes, fmt.Sprintf("%s: image dimensions %dx%d set to probed %dx%d", path, *width, *height, d.Width, d.Height))
+			*width, *height = d.Width, d.Height
+			changed = true
+		}
+	}
+	if in.opts.TooSmall(*width, *height) {
+		in.changes = append(in.changes, fmt.Sprintf("%s: image %dx%d is below the minimum size %dx%d", path, *width, *height, in.opts.MinWidth, in.opts.MinHeight))
+	}
+	return changed
+}
+
+func (in *imageNormaliser) probe(source string) (images.Dimensions, error) {
+	if d, ok := in.probed[source]; ok {
+		return d, nil
+	}
+	ctx := context.Background()
+	if in.opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, in.opts.Timeout)
+		defer cancel()
+	}
+	d, err := images.Probe(ctx, in.opts.Fetcher, source)
+	if err != nil {
+		return d, err
+	}
+	in.probed[source] = d
+	return d, nil
+}
+
+// setObjectFields sets the fields of top level object of the mitem leaving all its other fields untouched
+func setObjectFields(data json.RawMessage, name string, values map[string]interface{}) (json.RawMessage, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, fmt.Errorf("Unable to unmarshal passed mitem, error = %s", err.Error())
+	}
+	object := map[string]json.RawMessage{}
+	if raw, ok := fields[name]; ok && string(raw) != "null" {
+		if err := json.Unmarshal(raw, &object); err != nil {
+			return nil, fmt.Errorf("Unable to unmarshal %s, error = %s", name, err.Error())
+		}
+	}
+	for key, value := range values {
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+		object[key] = encoded
+	}
+	return setField(data, name, object)
+}
+
+// SelectMainImageStep fills the missing mainimage with the best image of the body, gallery ones included,
+// see images.Best. Tracking pixels and the images matching meta.logoURL are never picked.
+// The fallback is recorded with meta.mainImageFallback. The step runs after NormaliseImagesStep
+// so the candidates' sources are resolved and their dimensions known.
+func SelectMainImageStep() ProcessStep {
+	return ProcessStep{
+		Name:  StepSelectMainImage,
+		Order: 60,
+		Func: func(data json.RawMessage) (json.RawMessage, []string, error) {
+			var mt model.MitemTiniest
+			if err := json.Unmarshal(data, &mt); err != nil {
+				return nil, nil, fmt.Errorf("Unable to unmarshal passed mitem, error = %s", err.Error())
+			}
+			if len(strings.TrimSpace(mt.MainImage.Source)) > 0 {
+				return data, nil, nil
+			}
+			body, err := model.DecodeBody(mt.Body)
+			if err != nil {
+				return nil, nil, err
+			}
+			best, path, ok := bestBodyImage(body, mt.Meta.LogoURL)
+			if !ok {
+				return data, []string{"mainimage: missing, no suitable image in the body"}, nil
+			}
+			fields := map[string]interface{}{"source": best.Source, "caption": best.Caption, "width": best.Width, "height": best.Height}
+			processed, err := setObjectFields(data, "mainimage", fields)
+			if err != nil {
+				return nil, nil, err
+			}
+			if processed, err = setObjectFields(processed, "meta", map[string]interface{}{"mainImageFallback": true}); err != nil {
+				return nil, nil, err
+			}
+			return processed, []string{fmt.Sprintf("mainimage: missing, fallback to %s image %s", path, best.Source)}, nil
+		},
+	}
+}
+
+// bestBodyImage picks the best image of the body to replace the missing main image, see images.Best.
+// The JSON pointer of the picked image is returned along with it.
+func bestBodyImage(body []model.BodyElement, logoURL string) (images.Candidate, string, bool) {
+	var candidates []images.Candidate
+	paths := map[string]string{}
+	model.WalkBody(body, func(path string, element model.BodyElement) {
+		if e, ok := element.(*model.ImageElement); ok {
+			candidates = append(candidates, images.Candidate{Source: e.Source, Caption: e.Caption, Width: e.Width, Height: e.Height})
+			if _, ok := paths[e.Source]; !ok {
+				paths[e.Source] = path
+			}
+		}
+	})
+	best, ok := images.Best(candidates, logoURL)
+	if !ok {
+		return images.Candidate{}, "", false
+	}
+	return best, paths[best.Source], true
+}
@@ -4,10 +4,12 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"sync"
 	"time"
 
+	"github.com/jedynykaban/testkeyholder/canonical"
 	"github.com/jedynykaban/testkeyholder/model"
-	"github.com/jinzhu/now"
+	"github.com/jedynykaban/testkeyholder/schema"
 
 	log "github.com/Sirupsen/logrus"
 )
@@ -15,165 +17,246 @@ import (
 // Kojo allows one to deal with mysterious mitem's structure.
 // Here's the deal tell me what you want to extract from the mitem and I will do it.
 type Kojo interface {
+	Parse(data json.RawMessage) (*ParsedMitem, error)
 	GetMitemTiniest(data json.RawMessage) (*model.MitemTiniest, error)
 	GetSourceURL(data json.RawMessage) (string, error)
+	GetCanonicalURL(data json.RawMessage) (string, error)
+	GetDedupKey(data json.RawMessage) (string, error)
+	MakeCanonicalURL(meta *model.Meta) (string, error)
 	GetCreationDate(data json.RawMessage) (time.Time, error)
 	ConvertCreationDate(mt *model.MitemTiniest) (time.Time, error)
+	MatchCreationDate(mt *model.MitemTiniest) (model.DateMatch, error)
 	GetCategory(data json.RawMessage) (string, error)
 	GetCategoryPath(data json.RawMessage) (string, error)
 	MakeCategoryPath(cat *model.CategoryTiniest) string
-	GetAuthors(data json.RawMessage) ([]string, error)
+	GetAuthors(data json.RawMessage) ([]model.Author, []error, error)
 	GetLogoURL(data json.RawMessage) (string, error)
 	GetStatus(data json.RawMessage) (int, error)
 	GetBody(data json.RawMessage) ([]json.RawMessage, error)
+	GetPlainText(data json.RawMessage, opts model.TextOptions) (string, error)
+	GetTextStats(data json.RawMessage, opts model.TextOptions) (*model.TextStats, error)
+	ConvertMitem(data json.RawMessage) (*model.TheNewMitem, error)
+	ConvertMitemTiniest(mt *model.MitemTiniest) (*model.TheNewMitem, error)
 	Validate(data json.RawMessage) []error
+	ValidateSchema(data json.RawMessage) []error
 	Process(input json.RawMessage) (json.RawMessage, error)
+	ProcessFor(publisherID string, input json.RawMessage) (*ProcessResult, error)
 }
 
 // kojoService implements Kojo interface
 type kojoService struct {
+	pipeline *Pipeline
+	dates    *model.DateLayouts
+	utcDates bool
+	urls     *canonical.Canonicalizer
+	profiles *model.ValidationProfiles
+
+	schemaOnce sync.Once
+	schema     *schema.Schema
 }
 
-var _ Kojo = &kojoService{}
+// KojoOption allows one to customise the kojoService created by NewKojo
+type KojoOption func(ks *kojoService)
+
+// WithPipeline sets the processing pipeline used by Process and ProcessFor, DefaultPipeline is used otherwise
+func WithPipeline(p *Pipeline) KojoOption {
+	return func(ks *kojoService) {
+		ks.pipeline = p
+	}
+}
+
+// WithDateLayouts sets the date layouts registry used to parse creation dates
+func WithDateLayouts(dl *model.DateLayouts) KojoOption {
+	return func(ks *kojoService) {
+		ks.dates = dl
+	}
+}
+
+// WithCanonicalizer sets the canonicaliser used to make canonical source URLs and dedup keys
+func WithCanonicalizer(c *canonical.Canonicalizer) KojoOption {
+	return func(ks *kojoService) {
+		ks.urls = c
+	}
+}
 
-func init() {
-	// we support all the formats from the time package
-	var supportedTimeFormats = []string{
-		time.ANSIC,                          // "Mon Jan _2 15:04:05 2006"
-		time.UnixDate,                       // "Mon Jan _2 15:04:05 MST 2006"
-		time.RubyDate,                       // "Mon Jan 02 15:04:05 -0700 2006"
-		time.RFC822,                         // "02 Jan 06 15:04 MST"
-		time.RFC822Z,                        // "02 Jan 06 15:04 -0700" // RFC822 with numeric zone
-		time.RFC850,                         // "Monday, 02-Jan-06 15:04:05 MST"
-		time.RFC1123,                        // "Mon, 02 Jan 2006 15:04:05 MST"
-		time.RFC1123Z,                       // "Mon, 02 Jan 2006 15:04:05 -0700" // RFC1123 with numeric zone
-		time.RFC3339,                        // "2006-01-02T15:04:05Z07:00"
-		time.RFC3339Nano,                    // "2006-01-02T15:04:05.999999999Z07:00"
-		time.Kitchen,                        // "3:04PM"
-		time.Stamp,                          // "Jan _2 15:04:05"
-		time.StampMilli,                     // "Jan _2 15:04:05.000"
-		time.StampMicro,                     // "Jan _2 15:04:05.000000"
-		time.StampNano,                      // "Jan _2 15:04:05.000000000"
-		"Mon Jan 02 2006 15:04:05 MST-0700", //Custom format for svt.se feed
-	}
-	for _, format := range supportedTimeFormats {
-		now.TimeFormats = append(now.TimeFormats, format)
+// WithValidationProfiles sets the validation profiles applied by Validate on top of the mandatory fields check
+func WithValidationProfiles(vp *model.ValidationProfiles) KojoOption {
+	return func(ks *kojoService) {
+		ks.profiles = vp
 	}
 }
 
+// WithUTCDates turns on the date normalisation mode: creation dates are always returned in UTC,
+// dates without a zone are read in the publisher's default location
+// and incomplete or implausible dates are rejected (see model.DateLayouts.Normalize)
+func WithUTCDates() KojoOption {
+	return func(ks *kojoService) {
+		ks.utcDates = true
+	}
+}
+
+var _ Kojo = &kojoService{}
+
 // New - ctor like function - creates an instance of kojoService object
-func NewKojo() Kojo {
+func NewKojo(opts ...KojoOption) Kojo {
 	//gaService, err := ga.New("", "", "")
 	// if err != nil {
 	// 	log.WithError(err).Errorln("Error while fetching data from GA service")
 	// }
-	return &kojoService{}
+	ks := &kojoService{}
+	for _, opt := range opts {
+		opt(ks)
+	}
+	if ks.dates == nil {
+		ks.dates = model.DefaultDateLayouts
+	}
+	if ks.urls == nil {
+		ks.urls = canonical.Default
+	}
+	if ks.pipeline == nil {
+		ks.pipeline = DefaultPipeline(PipelineOptions{Dates: ks.dates, URLs: ks.urls})
+	}
+	if ks.profiles == nil {
+		ks.profiles = model.DefaultValidationProfiles
+	}
+	return ks
 }
 
 // GetSourceURL: extracts sourcURL field from the mitem structure
 func (ks *kojoService) GetSourceURL(data json.RawMessage) (string, error) {
-	var mt model.MitemTiniest
-	err := json.Unmarshal(data, &mt)
+	pm, err := ks.Parse(data)
+	if err != nil {
+		return "", fmt.Errorf("Unable to unmarshal passed mitem to mitemTines, error = %s", err.Error())
+	}
+	return pm.SourceURL()
+}
+
+// GetCanonicalURL: extracts sourceURL field from the mitem structure and makes its canonical form
+func (ks *kojoService) GetCanonicalURL(data json.RawMessage) (string, error) {
+	pm, err := ks.Parse(data)
 	if err != nil {
 		return "", fmt.Errorf("Unable to unmarshal passed mitem to mitemTines, error = %s", err.Error())
 	}
-	if len(mt.SourceURL) == 0 {
-		return "", errors.New("Either sourceURL field not present in the mitem or it is empty.")
+	return pm.CanonicalURL()
+}
+
+// GetDedupKey: makes the dedup key out of the mitem's sourceURL field
+func (ks *kojoService) GetDedupKey(data json.RawMessage) (string, error) {
+	pm, err := ks.Parse(data)
+	if err != nil {
+		return "", fmt.Errorf("Unable to unmarshal passed mitem to mitemTines, error = %s", err.Error())
 	}
-	return mt.SourceURL, nil
+	return pm.DedupKey()
 }
 
-func (ks *kojoService) GetBody(data json.RawMessage) ([]json.RawMessage, error) {
-	type tsukijiMitem struct {
-		Body []json.RawMessage `json:"body"`
+// MakeCanonicalURL: makes canonical source URL out of Meta structure.
+// WordPress permalinks of the mitems created inside mosaiq are resolved against MosaiqPrimary.Domain,
+// or the publisher's primary domain when it is not set.
+func (ks *kojoService) MakeCanonicalURL(meta *model.Meta) (string, error) {
+	var domain string
+	if meta.MosaiqPrimary.Set {
+		domain = meta.MosaiqPrimary.Domain
+		if len(domain) == 0 {
+			domain = ks.urls.PrimaryDomain(meta.SourceURL)
+		}
 	}
+	return ks.urls.Canonicalize(meta.SourceURL, domain)
+}
 
-	mitem := tsukijiMitem{}
-	err := json.Unmarshal(data, &mitem)
+// GetBody: extracts raw body elements from the mitem structure
+func (ks *kojoService) GetBody(data json.RawMessage) ([]json.RawMessage, error) {
+	pm, err := ks.Parse(data)
 	if err != nil {
 		log.WithFields(log.Fields{"error": err}).Error("Unable to decode passed mitem")
 		return nil, err
 	}
-	return mitem.Body, nil
+	return pm.Body(), nil
+}
+
+// GetPlainText: renders the mitem's body as plain text, see model.PlainText
+func (ks *kojoService) GetPlainText(data json.RawMessage, opts model.TextOptions) (string, error) {
+	stats, err := ks.GetTextStats(data, opts)
+	if err != nil {
+		return "", err
+	}
+	return stats.Text, nil
 }
 
-// GetAuthors: extracts authors names from the mitem structure
-func (ks *kojoService) GetAuthors(data json.RawMessage) ([]string, error) {
-	var rawMitem map[string]interface{}
-	err := json.Unmarshal(data, &rawMitem)
+// GetTextStats: derives plain text, word count, reading time and excerpt from the mitem's body
+func (ks *kojoService) GetTextStats(data json.RawMessage, opts model.TextOptions) (*model.TextStats, error) {
+	pm, err := ks.Parse(data)
 	if err != nil {
 		log.WithFields(log.Fields{"error": err}).Error("Unable to decode passed mitem")
 		return nil, err
 	}
-	// extract authors collection from the mitem as interface
-	ai, ok := rawMitem["authors"]
-	if !ok {
-		return nil, errors.New("Unable to extract authors from the mitem. No such field found")
-	}
-	// convert to collection
-	authors, ok := ai.([]interface{})
-	if !ok {
-		return nil, errors.New("Type assertion error unable to cast authors value to []interface{} type")
-	}
-	if len(authors) == 0 {
-		return nil, nil
-	}
-	var ret []string
-	for _, ai := range authors {
-		author, ok := ai.(map[string]interface{})
-		if !ok {
-			return nil, errors.New("Type assertion error unable to cast author interface value to map[string]interface{} type")
-		}
-		// extract name field
-		ni, ok := author["name"]
-		if !ok {
-			return nil, errors.New("Unable to extract name from the author. No such field found")
-		}
-		name, ok := ni.(string)
-		if !ok {
-			return nil, errors.New("Type assertion error unable to cast author name value to string type")
-		}
-		if len(name) > 0 {
-			ret = append(ret, name)
-		}
+	return pm.TextStats(opts)
+}
+
+// GetAuthors: extracts authors from the mitem structure.
+// Authors can be given as bylines, objects or a mix of both, see ParsedMitem.Authors.
+// Authors that cannot be read are skipped and returned as warnings.
+func (ks *kojoService) GetAuthors(data json.RawMessage) ([]model.Author, []error, error) {
+	pm, err := ks.Parse(data)
+	if err != nil {
+		log.WithFields(log.Fields{"error": err}).Error("Unable to decode passed mitem")
+		return nil, nil, err
 	}
-	return ret, nil
+	return pm.Authors()
 }
 
 // GetCreationDate: extracts date field from the mitem structure
 func (ks *kojoService) GetCreationDate(data json.RawMessage) (time.Time, error) {
-	var mt model.MitemTiniest
-	var ret time.Time
-	err := json.Unmarshal(data, &mt)
+	pm, err := ks.Parse(data)
 	if err != nil {
-		return ret, errors.New("Unable to unmarshal passed mitem")
+		return time.Time{}, errors.New("Unable to unmarshal passed mitem")
 	}
-	return ks.ConvertCreationDate(&mt)
+	return pm.CreationDate()
 }
 
 // ConvertCreationDate parses date string and converts to time.Time structure
 func (ks *kojoService) ConvertCreationDate(mt *model.MitemTiniest) (time.Time, error) {
-	return now.Parse(mt.Date)
+	match, err := ks.MatchCreationDate(mt)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return match.Time, nil
+}
+
+// MatchCreationDate parses date string with the layouts registered for the mitem's publisher
+// and tells which layout matched
+func (ks *kojoService) MatchCreationDate(mt *model.MitemTiniest) (model.DateMatch, error) {
+	var match model.DateMatch
+	var err error
+	if ks.utcDates {
+		var errs []error
+		match, errs = ks.dates.Normalize(mt.Date, model.PublisherKey(mt.SourceURL))
+		err = firstError(errs)
+	} else {
+		match, err = ks.dates.Parse(mt.Date, model.PublisherKey(mt.SourceURL))
+	}
+	if err != nil {
+		return match, err
+	}
+	log.WithFields(log.Fields{"date": mt.Date, "layout": match.Layout, "key": match.Key, "relaxed": match.Relaxed}).Debug("Creation date matched")
+	return match, nil
 }
 
 // GetCategory: extracts category field from the mitem structure
 func (ks *kojoService) GetCategory(data json.RawMessage) (string, error) {
-	var mt model.MitemTiniest
-	err := json.Unmarshal(data, &mt)
+	pm, err := ks.Parse(data)
 	if err != nil {
 		return "", errors.New("Unable to unmarshal passed mitem")
 	}
-	return mt.Category.Tier1, nil
+	return pm.Category(), nil
 }
 
 // GetCategoryPath: extracts category tier1 and tier2 fields from the mitem structure, and creates full path
 func (ks *kojoService) GetCategoryPath(data json.RawMessage) (string, error) {
-	var mt model.MitemTiniest
-	err := json.Unmarshal(data, &mt)
+	pm, err := ks.Parse(data)
 	if err != nil {
 		return "", errors.New("Unable to unmarshal passed mitem")
 	}
-	return ks.MakeCategoryPath(&mt.Category), nil
+	return pm.CategoryPath(), nil
 }
 
 // MakeCategoryPath: creates category path from Category structure
@@ -187,71 +270,128 @@ func (ks *kojoService) MakeCategoryPath(cat *model.CategoryTiniest) string {
 
 // GetLogoURL: extracts logo URL field from the mitem structure
 func (ks *kojoService) GetLogoURL(data json.RawMessage) (string, error) {
-	var mt model.MitemTiniest
-	err := json.Unmarshal(data, &mt)
+	pm, err := ks.Parse(data)
 	if err != nil {
 		return "", errors.New("Unable to unmarshal passed mitem")
 	}
-	return mt.Meta.LogoURL, nil
+	return pm.LogoURL(), nil
 }
 
 // GetStatus: extracts status field from the mitem structure
 func (ks *kojoService) GetStatus(data json.RawMessage) (int, error) {
-	var mt model.MitemTiniest
-	err := json.Unmarshal(data, &mt)
+	pm, err := ks.Parse(data)
 	if err != nil {
 		return -1, errors.New("Unable to unmarshal passed mitem")
 	}
-	return mt.Status, nil
+	return pm.Status(), nil
 }
 
 // GetMitemTiniest: converts raw data into structure
 func (ks *kojoService) GetMitemTiniest(data json.RawMessage) (*model.MitemTiniest, error) {
-	var mt model.MitemTiniest
-	err := json.Unmarshal(data, &mt)
+	pm, err := ks.Parse(data)
 	if err != nil {
 		return nil, fmt.Errorf("Unable to unmarshal passed mitem to mitemTines, error = %s", err.Error())
 	}
-	return &mt, nil
+	return pm.Tiniest(), nil
 }
 
-// ProcessFunc is a definition of function used to process raw mitem data
-type processFunc func(data json.RawMessage) (json.RawMessage, error)
-
-// Process calls all process functions passed as arguments
+// Process runs the processing pipeline against the mitem
+// with the steps enabled for all publishers
 func (ks *kojoService) Process(input json.RawMessage) (json.RawMessage, error) {
-	return chainProcess(input)
+	res, err := ks.ProcessFor("", input)
+	if err != nil {
+		return nil, err
+	}
+	return res.Data, nil
 }
 
-// Calls all required processing functions in chain
-func chainProcess(input json.RawMessage, steps ...processFunc) (json.RawMessage, error) {
-	processed := input
-	var err error
-	for _, step := range steps {
-		processed, err = step(processed)
-		if err != nil {
-			return nil, err
-		}
-	}
-	return processed, nil
+// ProcessFor runs the processing pipeline against the mitem
+// with the steps enabled for the given publisher
+func (ks *kojoService) ProcessFor(publisherID string, input json.RawMessage) (*ProcessResult, error) {
+	return ks.pipeline.Run(publisherID, input)
 }
 
-// Validate mitems structure.
+// Validate mitems structure. The raw mitem is validated, it is not processed (see Process).
+// A missing main image is reported as a warning only when the body holds an image
+// the conversion falls back to (see ConvertMitem).
 // Note we don't immediately stop on first error.
 // Thus you can expect multiple error messages in the output.
+// All returned errors are of *model.ValidationError type.
 func (ks *kojoService) Validate(data json.RawMessage) []error {
 	log.Debug("Validating the mitem")
 	var ret []error
 	if len(data) <= 0 {
-		ret = append(ret, errors.New("An empty mitem passed in"))
+		ret = append(ret, model.NewValidationError("", model.CodeEmpty, nil, "An empty mitem passed in"))
 	} else {
 		var mt model.MitemTiniest
 		err := json.Unmarshal(data, &mt)
 		if err != nil {
-			ret = append(ret, errors.New("Unable to unmarshal passed mitem"))
+			ret = append(ret, model.NewValidationError("", model.CodeMalformed, nil, "Unable to unmarshal passed mitem"))
 		} else {
-			ret = append(ret, mt.Validate()...)
+			ret = append(ret, mt.ValidateWith(ks.dates, ks.profiles, data)...)
+			ks.acceptMainImageFallback(&mt, ret)
+			if ks.utcDates && len(mt.Date) > 0 {
+				ret = append(ret, ks.validateDateNormalisation(&mt)...)
+			}
 		}
 	}
 	return ret
 }
+
+// acceptMainImageFallback turns the missing main image error into a warning
+// when the body holds an image to fall back to
+func (ks *kojoService) acceptMainImageFallback(mt *model.MitemTiniest, errs []error) {
+	for _, err := range errs {
+		ve := model.AsValidationError(err)
+		if ve.Path != "/mainimage/source" || ve.Code != model.CodeRequired {
+			continue
+		}
+		body, err := model.DecodeBody(mt.Body)
+		if err != nil {
+			return
+		}
+		if best, path, ok := bestBodyImage(body, mt.Meta.LogoURL); ok {
+			ve.Severity = model.SeverityWarning
+			ve.Message = fmt.Sprintf("Mandatory field mainimage.source is empty, %s image %s is used instead", path, best.Source)
+		}
+		return
+	}
+}
+
+// ValidateSchema checks the raw mitem against the JSON Schema of MitemTiniest (see schema.MitemTiniest)
+// instead of the validation rules. Validation profiles are not applied.
+// All returned errors are of *model.ValidationError type.
+func (ks *kojoService) ValidateSchema(data json.RawMessage) []error {
+	log.Debug("Validating the mitem against the schema")
+	return ks.mitemSchema().Validate(data)
+}
+
+func (ks *kojoService) mitemSchema() *schema.Schema {
+	ks.schemaOnce.Do(func() {
+		ks.schema = schema.MitemTiniest()
+	})
+	return ks.schema
+}
+
+// validateDateNormalisation reports the date problems found by the normalisation,
+// an unsupported format is reported by the mitem validation already
+func (ks *kojoService) validateDateNormalisation(mt *model.MitemTiniest) []error {
+	var ret []error
+	_, errs := ks.dates.Normalize(mt.Date, model.PublisherKey(mt.SourceURL))
+	for _, err := range errs {
+		if model.AsValidationError(err).Code != model.CodeInvalidFormat {
+			ret = append(ret, err)
+		}
+	}
+	return ret
+}
+
+// firstError returns the first error of SeverityError from the list
+func firstError(errs []error) error {
+	for _, err := range errs {
+		if model.AsValidationError(err).Severity != model.SeverityWarning {
+			return err
+		}
+	}
+	return nil
+}
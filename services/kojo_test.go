@@ -0,0 +1,232 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jedynykaban/testkeyholder/canonical"
+	"github.com/jedynykaban/testkeyholder/model"
+)
+
+func TestProcessSanitisesBody(t *testing.T) {
+	input := json.RawMessage(`{
+		"sourceURL": "https://www.svt.se/nyheter/a",
+		"date": "2020-01-02T10:00:00Z",
+		"type": "article",
+		"licensetype": "editorial",
+		"headline": "Headline",
+		"body": [
+			{"type": "paragraph", "content": "<b onclick=\"steal()\">Hello</b><script>steal()</script> world"},
+			{"type": "subhead", "content": "<a href=\"javascript:steal()\">link</a>"},
+			{"type": "paragraph", "content": "<img src=x onerror=steal()><i>fine</i>"}
+		]
+	}`)
+
+	processed, err := NewKojo().Process(input)
+	if err != nil {
+		t.Fatalf("Process unexpected error = %v", err)
+	}
+	var mitem struct {
+		Body []struct {
+			Type    string `json:"type"`
+			Content string `json:"content"`
+		} `json:"body"`
+	}
+	if err := json.Unmarshal(processed, &mitem); err != nil {
+		t.Fatalf("Unable to unmarshal processed mitem, error = %v", err)
+	}
+	want := []string{"<b>Hello</b> world", "link", "<i>fine</i>"}
+	if len(mitem.Body) != len(want) {
+		t.Fatalf("Processed body has %d elements, want = %d", len(mitem.Body), len(want))
+	}
+	for idx, element := range mitem.Body {
+		for _, unsafe := range []string{"script", "onclick", "onerror", "javascript:", "steal"} {
+			if strings.Contains(element.Content, unsafe) {
+				t.Errorf("body[%d] content %q contains %q", idx, element.Content, unsafe)
+			}
+		}
+		if element.Content != want[idx] {
+			t.Errorf("body[%d] content got = %q, want = %q", idx, element.Content, want[idx])
+		}
+	}
+}
+
+func TestMainImageFallback(t *testing.T) {
+	input := json.RawMessage(`{
+		"sourceURL": "https://www.svt.se/nyheter/a",
+		"date": "2020-01-02T10:00:00Z",
+		"type": "article",
+		"licensetype": "editorial",
+		"headline": "Headline",
+		"body": [
+			{"type": "image", "source": "https://www.svt.se/pixel.gif", "width": 1, "height": 1},
+			{"type": "image", "source": "https://www.svt.se/photo.jpg", "width": 1280, "height": 720}
+		]
+	}`)
+	kojo := NewKojo()
+	errs := kojo.Validate(input)
+	if model.HasErrors(errs) {
+		t.Errorf("Validate unexpected errors = %v", errs)
+	}
+	if len(errs) != 1 || model.AsValidationError(errs[0]).Path != "/mainimage/source" {
+		t.Errorf("Validate got = %v, want the warning about the main image fallback", errs)
+	}
+	converted, err := kojo.ConvertMitem(input)
+	if err != nil {
+		t.Fatalf("ConvertMitem unexpected error = %v", err)
+	}
+	if converted.MainImage.Source != "https://www.svt.se/photo.jpg" || !converted.Meta.MainImageFallback {
+		t.Errorf("ConvertMitem main image got = %s (fallback %t), want = https://www.svt.se/photo.jpg (fallback true)",
+			converted.MainImage.Source, converted.Meta.MainImageFallback)
+	}
+
+	withoutImages := json.RawMessage(`{
+		"sourceURL": "https://www.svt.se/nyheter/a",
+		"date": "2020-01-02T10:00:00Z",
+		"type": "article",
+		"licensetype": "editorial",
+		"headline": "Headline",
+		"body": [{"type": "paragraph", "content": "text"}]
+	}`)
+	if errs := kojo.Validate(withoutImages); !model.HasErrors(errs) {
+		t.Errorf("Validate did not report the missing main image, got = %v", errs)
+	}
+}
+
+func TestValidateReportsRawInput(t *testing.T) {
+	input := json.RawMessage(`{
+		"sourceURL": "https://www.svt.se/nyheter/a?utm_source=fb",
+		"date": "2020-01-02T10:00:00Z",
+		"type": "article",
+		"licensetype": "paid",
+		"mainimage": {"source": "https://www.svt.se/a.jpg"},
+		"headline": "Headline",
+		"body": [{"type": "paragraph", "content": "<script>x</script>"}, {"type": "video", "source": "abc", "videoType": "realplayer"}]
+	}`)
+	var got []string
+	for _, err := range NewKojo().Validate(input) {
+		ve := model.AsValidationError(err)
+		got = append(got, fmt.Sprintf("%s %v", ve.Path, ve.Value))
+	}
+	want := []string{"/body/1/videoType realplayer", "/licensetype paid"}
+	sort.Strings(got)
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("Validate got = %v, want = %v", got, want)
+	}
+}
+
+func TestConvertMitemSanitisesBody(t *testing.T) {
+	for _, date := range []string{"2020-01-02T10:00:00Z", "2099-01-02T10:00:00Z", "1970-01-02T10:00:00Z"} {
+		input := json.RawMessage(`{
+			"sourceURL": "https://www.svt.se/nyheter/a",
+			"date": "` + date + `",
+			"type": "article",
+			"headline": "Headline",
+			"mainimage": {"source": "https://www.svt.se/a.jpg"},
+			"body": [{"type": "paragraph", "content": "<b onclick=\"steal()\">Hello</b><script>steal()</script>"},
+				{"type": "gallery", "body": [{"type": "info", "content": "<iframe src=x></iframe>fine"}]}]
+		}`)
+		converted, err := NewKojo().ConvertMitem(input)
+		if err != nil {
+			t.Errorf("%s: ConvertMitem unexpected error = %v", date, err)
+			continue
+		}
+		var body string
+		for _, element := range converted.Body {
+			body += string(element)
+		}
+		for _, unsafe := range []string{"script", "onclick", "iframe", "steal"} {
+			if strings.Contains(body, unsafe) {
+				t.Errorf("%s: ConvertMitem body %s contains %q", date, body, unsafe)
+			}
+		}
+	}
+}
+
+func TestValidateUsesKojoDateLayouts(t *testing.T) {
+	input := json.RawMessage(`{
+		"sourceURL": "https://nyheter.example.com/a",
+		"date": "02.01.2020 kl 10:00",
+		"type": "article",
+		"licensetype": "editorial",
+		"mainimage": {"source": "https://example.com/a.jpg"},
+		"headline": "Headline",
+		"body": [{"type": "paragraph", "content": "text"}]
+	}`)
+	dl := model.NewDateLayouts()
+	dl.Register("example.com", "02.01.2006 kl 15:04")
+	for _, err := range NewKojo(WithDateLayouts(dl)).Validate(input) {
+		t.Errorf("Validate unexpected error = %v", err)
+	}
+}
+
+func TestCanonicalURLUsesPrimaryDomain(t *testing.T) {
+	urls := canonical.New()
+	urls.SetPrimaryDomain("mosaiq.example.com", "skonahem.com")
+	kojo := NewKojo(WithCanonicalizer(urls))
+	tests := []struct {
+		input json.RawMessage
+		want  string
+	}{
+		{json.RawMessage(`{"sourceURL": "http://mosaiq.example.com/?p=64187", "meta": {"mosaiqPrimary": true}}`), "http://skonahem.com/?p=64187"},
+		{json.RawMessage(`{"sourceURL": "http://mosaiq.example.com/?p=64187"}`), "http://mosaiq.example.com/?p=64187"},
+	}
+	for _, tt := range tests {
+		got, err := kojo.GetCanonicalURL(tt.input)
+		if err != nil {
+			t.Fatalf("GetCanonicalURL(%s) unexpected error = %v", tt.input, err)
+		}
+		if got != tt.want {
+			t.Errorf("GetCanonicalURL(%s) got = %s, want = %s", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestUTCDates(t *testing.T) {
+	stockholm, err := time.LoadLocation("Europe/Stockholm")
+	if err != nil {
+		t.Skipf("Zone database not available, error = %v", err)
+	}
+	dl := model.NewDateLayouts()
+	dl.SetLocation("svt.se", stockholm)
+	kojo := NewKojo(WithDateLayouts(dl), WithUTCDates())
+
+	got, err := kojo.GetCreationDate(json.RawMessage(`{"sourceURL": "https://nyheter.svt.se/a", "date": "2020-07-02 10:00:00"}`))
+	if err != nil {
+		t.Fatalf("GetCreationDate unexpected error = %v", err)
+	}
+	if want := time.Date(2020, 7, 2, 8, 0, 0, 0, time.UTC); !got.Equal(want) || got.Location() != time.UTC {
+		t.Errorf("GetCreationDate got = %v, want = %v", got, want)
+	}
+
+	tests := []struct {
+		date string
+		want string
+	}{
+		{"1980-01-02", model.CodeDateOutOfRange},
+		{"3:04PM", model.CodeDateIncomplete},
+		{"2020-07-02 10:00:00", ""},
+	}
+	for _, tt := range tests {
+		input := json.RawMessage(`{
+			"sourceURL": "https://nyheter.svt.se/a",
+			"date": "` + tt.date + `",
+			"type": "article",
+			"licensetype": "editorial",
+			"mainimage": {"source": "https://www.svt.se/a.jpg"},
+			"headline": "Headline",
+			"body": [{"type": "paragraph", "content": "text"}]
+		}`)
+		var codes []string
+		for _, err := range kojo.Validate(input) {
+			codes = append(codes, model.AsValidationError(err).Code)
+		}
+		if len(tt.want) == 0 && len(codes) > 0 || len(tt.want) > 0 && (len(codes) != 1 || codes[0] != tt.want) {
+			t.Errorf("Validate of date %q got codes = %v, want = %q", tt.date, codes, tt.want)
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/jedynykaban/testkeyholder/canonical"
+	"github.com/jedynykaban/testkeyholder/model"
+)
+
+// ParsedMitem is a mitem decoded once from the raw data.
+// It serves the same extractions as the Kojo getters without unmarshalling the mitem again,
+// use it whenever more than one field is needed.
+type ParsedMitem struct {
+	ks      *kojoService
+	mt      model.MitemTiniest
+	authors json.RawMessage
+}
+
+// parsedMitemData is the shape the raw mitem is decoded into
+type parsedMitemData struct {
+	model.MitemTiniest
+	Authors json.RawMessage `json:"authors"`
+}
+
+// Parse decodes the raw mitem once and returns a handle serving all the extractions
+func (ks *kojoService) Parse(data json.RawMessage) (*ParsedMitem, error) {
+	var pd parsedMitemData
+	if err := json.Unmarshal(data, &pd); err != nil {
+		return nil, err
+	}
+	return &ParsedMitem{ks: ks, mt: pd.MitemTiniest, authors: pd.Authors}, nil
+}
+
+// Tiniest returns the decoded mitem structure
+func (pm *ParsedMitem) Tiniest() *model.MitemTiniest {
+	return &pm.mt
+}
+
+// SourceURL returns the sourceURL field, it fails when the field is empty
+func (pm *ParsedMitem) SourceURL() (string, error) {
+	if len(pm.mt.SourceURL) == 0 {
+		return "", errors.New("Either sourceURL field not present in the mitem or it is empty.")
+	}
+	return pm.mt.SourceURL, nil
+}
+
+// CanonicalURL returns the canonical form of the sourceURL field, see canonical.Canonicalize.
+// Mitems created inside mosaiq are resolved against the publisher's primary domain.
+func (pm *ParsedMitem) CanonicalURL() (string, error) {
+	sourceURL, err := pm.SourceURL()
+	if err != nil {
+		return "", err
+	}
+	return pm.ks.urls.Canonicalize(sourceURL, primaryDomain(pm.ks.urls, &pm.mt))
+}
+
+// DedupKey returns the dedup key of the sourceURL field, see canonical.Key
+func (pm *ParsedMitem) DedupKey() (string, error) {
+	canonicalURL, err := pm.CanonicalURL()
+	if err != nil {
+		return "", err
+	}
+	return canonical.Hash(canonicalURL), nil
+}
+
+// Body returns raw body elements
+func (pm *ParsedMitem) Body() []json.RawMessage {
+	return pm.mt.Body
+}
+
+// TextStats returns plain text of the body along with the stats derived from it, see model.AnalyzeText
+func (pm *ParsedMitem) TextStats(opts model.TextOptions) (*model.TextStats, error) {
+	body, err := model.DecodeBody(pm.mt.Body)
+	if err != nil {
+		return nil, err
+	}
+	stats := model.AnalyzeText(pm.mt.Headline, body, opts)
+	return &stats, nil
+}
+
+// Authors returns de-duplicated authors. The authors field can hold a byline
+// ("Anna Svensson och Erik Berg"), an author object or a list of both.
+// PlaylistName is taken from the author object or made out of the name.
+// A missing authors field means no authors, authors that cannot be read
+// are skipped and returned as warnings (*model.ValidationError of SeverityWarning).
+func (pm *ParsedMitem) Authors() ([]model.Author, []error, error) {
+	return parseAuthors(pm.authors)
+}
+
+// CreationDate returns the date field converted to time.Time structure
+func (pm *ParsedMitem) CreationDate() (time.Time, error) {
+	return pm.ks.ConvertCreationDate(&pm.mt)
+}
+
+// Category returns the category tier1
+func (pm *ParsedMitem) Category() string {
+	return pm.mt.Category.Tier1
+}
+
+// CategoryPath returns full category path made of tier1 and tier2
+func (pm *ParsedMitem) CategoryPath() string {
+	return pm.ks.MakeCategoryPath(&pm.mt.Category)
+}
+
+// LogoURL returns the logo URL from the meta
+func (pm *ParsedMitem) LogoURL() string {
+	return pm.mt.Meta.LogoURL
+}
+
+// Status returns the status field
+func (pm *ParsedMitem) Status() int {
+	return pm.mt.Status
+}
+
+// ErrUnknownField is returned by Field when there is no such field
+var ErrUnknownField = errors.New("Unknown field")
+
+// fields maps field names to the extractions, names follow the Kojo getters
+var fields = map[string]func(pm *ParsedMitem) (interface{}, error){
+	"sourceURL":    func(pm *ParsedMitem) (interface{}, error) { return pm.SourceURL() },
+	"canonicalURL": func(pm *ParsedMitem) (interface{}, error) { return pm.CanonicalURL() },
+	"dedupKey":     func(pm *ParsedMitem) (interface{}, error) { return pm.DedupKey() },
+	"creationDate": func(pm *ParsedMitem) (interface{}, error) { return pm.CreationDate() },
+	"category":     func(pm *ParsedMitem) (interface{}, error) { return pm.Category(), nil },
+	"categoryPath": func(pm *ParsedMitem) (interface{}, error) { return pm.CategoryPath(), nil },
+	"authors": func(pm *ParsedMitem) (interface{}, error) {
+		authors, _, err := pm.Authors()
+		return authors, err
+	},
+	"logoURL": func(pm *ParsedMitem) (interface{}, error) { return pm.LogoURL(), nil },
+	"status":  func(pm *ParsedMitem) (interface{}, error) { return pm.Status(), nil },
+	"body":    func(pm *ParsedMitem) (interface{}, error) { return pm.Body(), nil },
+	"text":    func(pm *ParsedMitem) (interface{}, error) { return pm.TextStats(model.DefaultTextOptions) },
+	"tiniest": func(pm *ParsedMitem) (interface{}, error) { return pm.Tiniest(), nil },
+}
+
+// FieldNames returns sorted names of the fields Field can extract
+func FieldNames() []string {
+	ret := make([]string, 0, len(fields))
+	for name := range fields {
+		ret = append(ret, name)
+	}
+	sort.Strings(ret)
+	return ret
+}
+
+// Field extracts the field by its name, see FieldNames.
+// ErrUnknownField is returned when there is no such field.
+func (pm *ParsedMitem) Field(name string) (interface{}, error) {
+	extract, ok := fields[name]
+	if !ok {
+		return nil, ErrUnknownField
+	}
+	return extract(pm)
+}
@@ -0,0 +1,193 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jedynykaban/testkeyholder/model"
+)
+
+// largeMitem makes the gallery heavy mitem, the body is what makes the unmarshalling expensive
+func largeMitem(paragraphs, galleries, images int) json.RawMessage {
+	var body []string
+	for p := 0; p < paragraphs; p++ {
+		body = append(body, fmt.Sprintf(`{"type": "paragraph", "content": "Paragraph %d with <b>some</b> text of a typical length for an article, %s"}`,
+			p, strings.Repeat("lorem ipsum dolor sit amet ", 8)))
+	}
+	for g := 0; g < galleries; g++ {
+		var gallery []string
+		for i := 0; i < images; i++ {
+			gallery = append(gallery, fmt.Sprintf(`{"type": "image", "source": "https://img.svt.se/g%d/%d.jpg", "caption": "Image %d of gallery %d", "width": 1280, "height": 720}`, g, i, i, g))
+		}
+		body = append(body, `{"type": "gallery", "body": [`+strings.Join(gallery, ",")+`]}`)
+	}
+	return json.RawMessage(`{
+		"sourceURL": "https://www.svt.se/nyheter/a?utm_source=fb",
+		"date": "2020-01-02T10:00:00Z",
+		"type": "article",
+		"licensetype": "editorial",
+		"mainimage": {"source": "https://img.svt.se/main.jpg"},
+		"headline": "Headline",
+		"category": {"tier1": "news", "tier2": "sweden"},
+		"meta": {"logoURL": "https://www.svt.se/logo.png"},
+		"status": 1,
+		"authors": ["Anna Andersson och Bo Berg"],
+		"body": [` + strings.Join(body, ",") + `]
+	}`)
+}
+
+func TestParsedMitemMatchesGetters(t *testing.T) {
+	data := largeMitem(5, 2, 3)
+	kojo := NewKojo()
+	pm, err := kojo.Parse(data)
+	if err != nil {
+		t.Fatalf("Parse unexpected error = %v", err)
+	}
+
+	sourceURL, _ := kojo.GetSourceURL(data)
+	canonicalURL, _ := kojo.GetCanonicalURL(data)
+	dedupKey, _ := kojo.GetDedupKey(data)
+	creationDate, _ := kojo.GetCreationDate(data)
+	category, _ := kojo.GetCategoryPath(data)
+	authors, _, _ := kojo.GetAuthors(data)
+	pmSourceURL, _ := pm.SourceURL()
+	pmCanonicalURL, _ := pm.CanonicalURL()
+	pmDedupKey, _ := pm.DedupKey()
+	pmCreationDate, _ := pm.CreationDate()
+	pmAuthors, _, _ := pm.Authors()
+
+	tests := []struct {
+		name      string
+		got, want interface{}
+	}{
+		{"sourceURL", pmSourceURL, sourceURL},
+		{"canonicalURL", pmCanonicalURL, canonicalURL},
+		{"dedupKey", pmDedupKey, dedupKey},
+		{"creationDate", pmCreationDate, creationDate},
+		{"categoryPath", pm.CategoryPath(), category},
+		{"authors", pmAuthors, authors},
+	}
+	for _, tt := range tests {
+		if !reflect.DeepEqual(tt.got, tt.want) {
+			t.Errorf("%s: ParsedMitem got = %v, Kojo getter got = %v", tt.name, tt.got, tt.want)
+		}
+	}
+	if len(authors) != 2 {
+		t.Errorf("GetAuthors got = %v, want 2 authors", authors)
+	}
+}
+
+// extractGetters reads the fields the way the callers did before ParsedMitem: every getter unmarshals the mitem
+func extractGetters(b *testing.B, kojo Kojo, data json.RawMessage) {
+	if _, err := kojo.GetSourceURL(data); err != nil {
+		b.Fatal(err)
+	}
+	if _, err := kojo.GetCanonicalURL(data); err != nil {
+		b.Fatal(err)
+	}
+	if _, err := kojo.GetDedupKey(data); err != nil {
+		b.Fatal(err)
+	}
+	if _, err := kojo.GetCreationDate(data); err != nil {
+		b.Fatal(err)
+	}
+	if _, err := kojo.GetCategoryPath(data); err != nil {
+		b.Fatal(err)
+	}
+	if _, err := kojo.GetLogoURL(data); err != nil {
+		b.Fatal(err)
+	}
+	if _, _, err := kojo.GetAuthors(data); err != nil {
+		b.Fatal(err)
+	}
+	if _, err := kojo.GetTextStats(data, model.DefaultTextOptions); err != nil {
+		b.Fatal(err)
+	}
+}
+
+// extractParsed reads the same fields out of the mitem unmarshalled once
+func extractParsed(b *testing.B, kojo Kojo, data json.RawMessage) {
+	pm, err := kojo.Parse(data)
+	if err != nil {
+		b.Fatal(err)
+	}
+	if _, err := pm.SourceURL(); err != nil {
+		b.Fatal(err)
+	}
+	if _, err := pm.CanonicalURL(); err != nil {
+		b.Fatal(err)
+	}
+	if _, err := pm.DedupKey(); err != nil {
+		b.Fatal(err)
+	}
+	if _, err := pm.CreationDate(); err != nil {
+		b.Fatal(err)
+	}
+	pm.CategoryPath()
+	pm.LogoURL()
+	if _, _, err := pm.Authors(); err != nil {
+		b.Fatal(err)
+	}
+	if _, err := pm.TextStats(model.DefaultTextOptions); err != nil {
+		b.Fatal(err)
+	}
+}
+
+func benchmarkExtract(b *testing.B, extract func(b *testing.B, kojo Kojo, data json.RawMessage)) {
+	kojo := NewKojo()
+	data := largeMitem(50, 20, 30)
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		extract(b, kojo, data)
+	}
+}
+
+func BenchmarkExtractGetters(b *testing.B) {
+	benchmarkExtract(b, extractGetters)
+}
+
+func BenchmarkExtractParsedMitem(b *testing.B) {
+	benchmarkExtract(b, extractParsed)
+}
+
+// benchmarkKojo measures a Kojo entry point against the large mitem, the mitem should be unmarshalled once
+func benchmarkKojo(b *testing.B, call func(kojo Kojo, data json.RawMessage) error) {
+	kojo := NewKojo()
+	data := largeMitem(50, 20, 30)
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := call(kojo, data); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkValidate(b *testing.B) {
+	benchmarkKojo(b, func(kojo Kojo, data json.RawMessage) error {
+		if errs := kojo.Validate(data); model.HasErrors(errs) {
+			return errs[0]
+		}
+		return nil
+	})
+}
+
+func BenchmarkConvertMitem(b *testing.B) {
+	benchmarkKojo(b, func(kojo Kojo, data json.RawMessage) error {
+		_, err := kojo.ConvertMitem(data)
+		return err
+	})
+}
+
+func BenchmarkProcess(b *testing.B) {
+	benchmarkKojo(b, func(kojo Kojo, data json.RawMessage) error {
+		_, err := kojo.Process(data)
+		return err
+	})
+}
@@ -0,0 +1,220 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+
+	log "github.com/Sirupsen/logrus"
+
+	"github.com/jedynykaban/testkeyholder/canonical"
+	"github.com/jedynykaban/testkeyholder/images"
+	"github.com/jedynykaban/testkeyholder/model"
+	"github.com/jedynykaban/testkeyholder/sanitize"
+)
+
+// ProcessFunc is a definition of function used to process raw mitem data.
+// Besides the processed data it returns a human readable list of changes it made.
+type ProcessFunc func(data json.RawMessage) (json.RawMessage, []string, error)
+
+// ProcessStep is a named processing step that can be registered in the Pipeline
+type ProcessStep struct {
+	// Name identifies the step, it has to be unique within a pipeline
+	Name string
+	// Order defines when the step runs, steps with lower order run first.
+	// Steps with the same order run in the registration order.
+	Order int
+	// Disabled steps are skipped unless they are enabled for a publisher
+	Disabled bool
+	Func     ProcessFunc
+}
+
+// StepResult describes what a single step did to the mitem
+type StepResult struct {
+	Name    string   `json:"name"`
+	Skipped bool     `json:"skipped,omitempty"`
+	Changed bool     `json:"changed"`
+	Changes []string `json:"changes,omitempty"`
+}
+
+// ProcessResult holds the processed mitem along with the report of all the steps
+type ProcessResult struct {
+	Data  json.RawMessage `json:"data"`
+	Steps []StepResult    `json:"steps"`
+}
+
+// Pipeline is an ordered collection of processing steps.
+// Steps can be turned on or off per publisher.
+type Pipeline struct {
+	mu    sync.RWMutex
+	steps []ProcessStep
+	// publisher ID -> step name -> enabled
+	overrides map[string]map[string]bool
+}
+
+// NewPipeline creates a pipeline with the given steps registered
+func NewPipeline(steps ...ProcessStep) *Pipeline {
+	p := &Pipeline{overrides: make(map[string]map[string]bool)}
+	for _, step := range steps {
+		if err := p.Register(step); err != nil {
+			log.WithFields(log.Fields{"error": err}).Error("Unable to register processing step")
+		}
+	}
+	return p
+}
+
+// PipelineOptions customise the steps of the default pipeline, nil means the package default
+type PipelineOptions struct {
+	Dates  *model.DateLayouts
+	URLs   *canonical.Canonicalizer
+	HTML   *sanitize.Policy
+	Images *images.Options
+}
+
+// DefaultPipeline creates the pipeline with all the steps provided by the package, in the order they run:
+// normalise-date, canonicalise-url, sanitise-html, normalise-video, normalise-images and select-main-image
+func DefaultPipeline(opts PipelineOptions) *Pipeline {
+	if opts.Dates == nil {
+		opts.Dates = model.DefaultDateLayouts
+	}
+	if opts.URLs == nil {
+		opts.URLs = canonical.Default
+	}
+	if opts.HTML == nil {
+		opts.HTML = sanitize.DefaultPolicy
+	}
+	imageOpts := images.DefaultOptions
+	if opts.Images != nil {
+		imageOpts = *opts.Images
+	}
+	return NewPipeline(
+		NormaliseDateStep(opts.Dates),
+		CanonicaliseURLStep(opts.URLs),
+		SanitiseHTMLStep(opts.HTML),
+		NormaliseVideoStep(),
+		NormaliseImagesStep(imageOpts),
+		SelectMainImageStep(),
+	)
+}
+
+// Register adds the step to the pipeline
+func (p *Pipeline) Register(step ProcessStep) error {
+	if len(step.Name) == 0 {
+		return fmt.Errorf("Processing step has to have a name")
+	}
+	if step.Func == nil {
+		return fmt.Errorf("Processing step %s has no function", step.Name)
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, s := range p.steps {
+		if s.Name == step.Name {
+			return fmt.Errorf("Processing step %s already registered", step.Name)
+		}
+	}
+	p.steps = append(p.steps, step)
+	sort.SliceStable(p.steps, func(i, j int) bool { return p.steps[i].Order < p.steps[j].Order })
+	return nil
+}
+
+// Unregister removes the step from the pipeline
+func (p *Pipeline) Unregister(name string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for idx, s := range p.steps {
+		if s.Name == name {
+			p.steps = append(p.steps[:idx], p.steps[idx+1:]...)
+			return
+		}
+	}
+}
+
+// SetEnabled turns the step on or off for the publisher.
+// An empty publisher ID changes the default for all publishers.
+func (p *Pipeline) SetEnabled(publisherID, name string, enabled bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(publisherID) == 0 {
+		for idx := range p.steps {
+			if p.steps[idx].Name == name {
+				p.steps[idx].Disabled = !enabled
+			}
+		}
+		return
+	}
+	if p.overrides[publisherID] == nil {
+		p.overrides[publisherID] = make(map[string]bool)
+	}
+	p.overrides[publisherID][name] = enabled
+}
+
+// LoadToggles turns the steps on or off as read from config.
+// The map is keyed by publisher ID, the empty key changes the default for all publishers.
+func (p *Pipeline) LoadToggles(toggles map[string]map[string]bool) {
+	for publisherID, steps := range toggles {
+		for name, enabled := range steps {
+			p.SetEnabled(publisherID, name, enabled)
+		}
+	}
+}
+
+// Steps returns names of the registered steps in the order they run
+func (p *Pipeline) Steps() []string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	ret := make([]string, 0, len(p.steps))
+	for _, s := range p.steps {
+		ret = append(ret, s.Name)
+	}
+	return ret
+}
+
+func (p *Pipeline) enabled(publisherID string, step ProcessStep) bool {
+	if enabled, ok := p.overrides[publisherID][step.Name]; ok {
+		return enabled
+	}
+	return !step.Disabled
+}
+
+// Run calls all the steps enabled for the publisher in chain.
+// It stops on the first failing step.
+func (p *Pipeline) Run(publisherID string, input json.RawMessage) (*ProcessResult, error) {
+	p.mu.RLock()
+	steps := make([]ProcessStep, len(p.steps))
+	copy(steps, p.steps)
+	enabled := make([]bool, len(steps))
+	for idx, step := range steps {
+		enabled[idx] = p.enabled(publisherID, step)
+	}
+	p.mu.RUnlock()
+
+	ret := &ProcessResult{Data: input}
+	for idx, step := range steps {
+		if !enabled[idx] {
+			ret.Steps = append(ret.Steps, StepResult{Name: step.Name, Skipped: true})
+			continue
+		}
+		processed, changes, err := step.Func(ret.Data)
+		if err != nil {
+			return nil, fmt.Errorf("Processing step %s failed, error = %s", step.Name, err.Error())
+		}
+		ret.Steps = append(ret.Steps, StepResult{
+			Name:    step.Name,
+			Changed: !sameJSON(ret.Data, processed),
+			Changes: changes,
+		})
+		ret.Data = processed
+	}
+	return ret, nil
+}
+
+// sameJSON compares two JSON documents ignoring insignificant whitespace
+func sameJSON(a, b json.RawMessage) bool {
+	var ca, cb bytes.Buffer
+	if json.Compact(&ca, a) != nil || json.Compact(&cb, b) != nil {
+		return bytes.Equal(a, b)
+	}
+	return bytes.Equal(ca.Bytes(), cb.Bytes())
+}
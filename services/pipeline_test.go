@@ -0,0 +1,124 @@
+package services
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jedynykaban/testkeyholder/model"
+)
+
+func TestDefaultPipelineSteps(t *testing.T) {
+	want := []string{
+		StepNormaliseDate,
+		StepCanonicaliseURL,
+		StepSanitiseHTML,
+		StepNormaliseVideo,
+		StepNormaliseImages,
+		StepSelectMainImage,
+	}
+	if got := DefaultPipeline(PipelineOptions{}).Steps(); !reflect.DeepEqual(got, want) {
+		t.Errorf("DefaultPipeline steps got = %v, want = %v", got, want)
+	}
+}
+
+func TestPipelineToggles(t *testing.T) {
+	appendStep := func(name string, order int) ProcessStep {
+		return ProcessStep{Name: name, Order: order, Func: func(data json.RawMessage) (json.RawMessage, []string, error) {
+			var names []string
+			if err := json.Unmarshal(data, &names); err != nil {
+				return nil, nil, err
+			}
+			processed, err := json.Marshal(append(names, name))
+			return processed, nil, err
+		}}
+	}
+	p := NewPipeline(appendStep("b", 20), appendStep("a", 10), appendStep("c", 30))
+	p.LoadToggles(map[string]map[string]bool{
+		"":      {"c": false},
+		"svt":   {"a": false},
+		"aftbl": {"c": true},
+	})
+
+	tests := []struct {
+		publisherID string
+		want        string
+	}{
+		{"", `["a","b"]`},
+		{"svt", `["b"]`},
+		{"aftbl", `["a","b","c"]`},
+	}
+	for _, tt := range tests {
+		res, err := p.Run(tt.publisherID, json.RawMessage(`[]`))
+		if err != nil {
+			t.Fatalf("Run(%q) unexpected error = %v", tt.publisherID, err)
+		}
+		if string(res.Data) != tt.want {
+			t.Errorf("Run(%q) got = %s, want = %s", tt.publisherID, res.Data, tt.want)
+		}
+		if len(res.Steps) != 3 {
+			t.Errorf("Run(%q) reported %d steps, want = 3", tt.publisherID, len(res.Steps))
+		}
+	}
+}
+
+func TestNormaliseDateStep(t *testing.T) {
+	tests := []struct {
+		date    string
+		want    string
+		wantErr bool
+	}{
+		{"2020-01-02T10:00:00+01:00", "2020-01-02T09:00:00Z", false},
+		{"2020-01-02T09:00:00Z", "2020-01-02T09:00:00Z", false},
+		{"2099-01-02T10:00:00+01:00", "2099-01-02T10:00:00+01:00", false},
+		{"1970-01-02T10:00:00Z", "1970-01-02T10:00:00Z", false},
+		{"not a date at all", "", true},
+	}
+	step := NormaliseDateStep(model.NewDateLayouts())
+	for _, tt := range tests {
+		processed, changes, err := step.Func(json.RawMessage(`{"sourceURL": "https://www.svt.se/a", "date": "` + tt.date + `"}`))
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: error = %v, wantErr = %t", tt.date, err, tt.wantErr)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		var mt model.MitemTiniest
+		if err := json.Unmarshal(processed, &mt); err != nil {
+			t.Fatalf("%s: unable to unmarshal processed mitem, error = %v", tt.date, err)
+		}
+		if mt.Date != tt.want {
+			t.Errorf("%s: date got = %s, want = %s", tt.date, mt.Date, tt.want)
+		}
+		if tt.date != tt.want && len(changes) == 0 {
+			t.Errorf("%s: no changes reported", tt.date)
+		}
+	}
+}
+
+func TestProcessScheduledMitem(t *testing.T) {
+	input := json.RawMessage(`{
+		"sourceURL": "https://www.svt.se/nyheter/a?utm_source=fb",
+		"date": "2099-01-02T10:00:00Z",
+		"body": [{"type": "paragraph", "content": "<script>steal()</script>Hello"}]
+	}`)
+	res, err := NewKojo().ProcessFor("", input)
+	if err != nil {
+		t.Fatalf("ProcessFor unexpected error = %v", err)
+	}
+	var mt model.MitemTiniest
+	if err := json.Unmarshal(res.Data, &mt); err != nil {
+		t.Fatalf("Unable to unmarshal processed mitem, error = %v", err)
+	}
+	if mt.SourceURL != "https://www.svt.se/nyheter/a" {
+		t.Errorf("sourceURL got = %s, want it canonicalised", mt.SourceURL)
+	}
+	if len(mt.Body) != 1 || strings.Contains(string(mt.Body[0]), "script") {
+		t.Errorf("body got = %s, want it sanitised", mt.Body)
+	}
+	if mt.Date != "2099-01-02T10:00:00Z" || len(res.Steps) == 0 || len(res.Steps[0].Changes) == 0 {
+		t.Errorf("date got = %s, steps = %+v, want the date untouched and reported", mt.Date, res.Steps)
+	}
+}
@@ -0,0 +1,229 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/jedynykaban/testkeyholder/canonical"
+	"github.com/jedynykaban/testkeyholder/model"
+	"github.com/jedynykaban/testkeyholder/sanitize"
+	"github.com/jedynykaban/testkeyholder/video"
+)
+
+// Names of the processing steps provided by the package
+const (
+	StepNormaliseDate   = "normalise-date"
+	StepCanonicaliseURL = "canonicalise-url"
+	StepSanitiseHTML    = "sanitise-html"
+	StepNormaliseVideo  = "normalise-video"
+)
+
+// NormaliseDateStep rewrites the date field to RFC3339 in UTC.
+// The date is parsed with the layouts and default zones registered for the mitem's publisher.
+// Only a date that cannot be parsed at all makes the step fail. Incomplete and implausible dates
+// i.e. the scheduled ones are left untouched and reported as changes, it is up to the validation to reject them.
+func NormaliseDateStep(dl *model.DateLayouts) ProcessStep {
+	return ProcessStep{
+		Name:  StepNormaliseDate,
+		Order: 10,
+		Func: func(data json.RawMessage) (json.RawMessage, []string, error) {
+			var mt model.MitemTiniest
+			if err := json.Unmarshal(data, &mt); err != nil {
+				return nil, nil, fmt.Errorf("Unable to unmarshal passed mitem, error = %s", err.Error())
+			}
+			if len(mt.Date) == 0 {
+				return data, nil, nil
+			}
+			match, errs := dl.Normalize(mt.Date, model.PublisherKey(mt.SourceURL))
+			var changes []string
+			for _, err := range errs {
+				if model.AsValidationError(err).Code == model.CodeInvalidFormat {
+					return nil, nil, err
+				}
+				changes = append(changes, err.Error())
+			}
+			if model.HasErrors(errs) {
+				return data, append([]string{fmt.Sprintf("date %s left untouched", mt.Date)}, changes...), nil
+			}
+			normalised := match.Time.Format(time.RFC3339)
+			if normalised == mt.Date {
+				return data, changes, nil
+			}
+			processed, err := setField(data, "date", normalised)
+			if err != nil {
+				return nil, nil, err
+			}
+			return processed, append([]string{fmt.Sprintf("date %s normalised to %s", mt.Date, normalised)}, changes...), nil
+		},
+	}
+}
+
+// primaryDomain returns the publisher's primary domain for the mitems created inside mosaiq, empty otherwise
+func primaryDomain(c *canonical.Canonicalizer, mt *model.MitemTiniest) string {
+	if !mt.Meta.MosaiqPrimary {
+		return ""
+	}
+	return c.PrimaryDomain(mt.SourceURL)
+}
+
+// CanonicaliseURLStep rewrites the sourceURL field to its canonical form, see canonical.Canonicalize.
+// WordPress permalinks of the mitems created inside mosaiq are moved to the publisher's primary domain.
+func CanonicaliseURLStep(c *canonical.Canonicalizer) ProcessStep {
+	return ProcessStep{
+		Name:  StepCanonicaliseURL,
+		Order: 20,
+		Func: func(data json.RawMessage) (json.RawMessage, []string, error) {
+			var mt model.MitemTiniest
+			if err := json.Unmarshal(data, &mt); err != nil {
+				return nil, nil, fmt.Errorf("Unable to unmarshal passed mitem, error = %s", err.Error())
+			}
+			if len(mt.SourceURL) == 0 {
+				return data, nil, nil
+			}
+			canonicalURL, err := c.Canonicalize(mt.SourceURL, primaryDomain(c, &mt))
+			if err != nil {
+				return nil, nil, err
+			}
+			if canonicalURL == mt.SourceURL {
+				return data, nil, nil
+			}
+			processed, err := setField(data, "sourceURL", canonicalURL)
+			if err != nil {
+				return nil, nil, err
+			}
+			return processed, []string{fmt.Sprintf("sourceURL %s canonicalised to %s", mt.SourceURL, canonicalURL)}, nil
+		},
+	}
+}
+
+// SanitiseHTMLStep cleans the content of all the text body elements, gallery ones included,
+// leaving only the markup allowed by the policy (see sanitize.DefaultPolicy).
+// Every removed tag or attribute is reported along with the JSON pointer of the element.
+func SanitiseHTMLStep(p *sanitize.Policy) ProcessStep {
+	return ProcessStep{
+		Name:  StepSanitiseHTML,
+		Order: 30,
+		Func: func(data json.RawMessage) (json.RawMessage, []string, error) {
+			var mt model.MitemTiniest
+			if err := json.Unmarshal(data, &mt); err != nil {
+				return nil, nil, fmt.Errorf("Unable to unmarshal passed mitem, error = %s", err.Error())
+			}
+			body, err := model.DecodeBody(mt.Body)
+			if err != nil {
+				return nil, nil, err
+			}
+			changes := sanitiseBody(p, body)
+			if len(changes) == 0 {
+				return data, nil, nil
+			}
+			encoded, err := model.EncodeBody(body)
+			if err != nil {
+				return nil, nil, err
+			}
+			processed, err := setField(data, "body", encoded)
+			if err != nil {
+				return nil, nil, err
+			}
+			return processed, changes, nil
+		},
+	}
+}
+
+// sanitiseBody cleans the text elements in place and reports the changes made along with the elements' JSON pointers
+func sanitiseBody(p *sanitize.Policy, body []model.BodyElement) []string {
+	var changes []string
+	model.WalkBody(body, func(path string, element model.BodyElement) {
+		text, ok := element.(model.TextElement)
+		if !ok {
+			return
+		}
+		clean, removed := p.Sanitize(text.Text())
+		if clean == text.Text() {
+			return
+		}
+		text.SetText(clean)
+		for _, change := range removed {
+			changes = append(changes, path+": "+change.String())
+		}
+		if len(removed) == 0 {
+			changes = append(changes, path+": text escaped")
+		}
+	})
+	return changes
+}
+
+// NormaliseVideoStep rewrites the source of all the video elements, gallery ones included,
+// to the provider's video ID (the file URL for mp4 and hls) and fills their embed and thumbnail URLs.
+// A missing videoType is detected from the source URL. Videos that cannot be read are left untouched
+// and reported, it is up to the validation to reject them.
+func NormaliseVideoStep() ProcessStep {
+	return ProcessStep{
+		Name:  StepNormaliseVideo,
+		Order: 40,
+		Func: func(data json.RawMessage) (json.RawMessage, []string, error) {
+			var mt model.MitemTiniest
+			if err := json.Unmarshal(data, &mt); err != nil {
+				return nil, nil, fmt.Errorf("Unable to unmarshal passed mitem, error = %s", err.Error())
+			}
+			body, err := model.DecodeBody(mt.Body)
+			if err != nil {
+				return nil, nil, err
+			}
+			var changes []string
+			var changed bool
+			model.WalkBody(body, func(path string, element model.BodyElement) {
+				e, ok := element.(*model.VideoElement)
+				if !ok {
+					return
+				}
+				v, err := video.Parse(e.VideoType, e.Source)
+				if err != nil {
+					changes = append(changes, fmt.Sprintf("%s: left untouched, %s", path, err.Error()))
+					return
+				}
+				normalised := model.VideoElement{Source: v.ID, VideoType: v.Provider, EmbedURL: v.EmbedURL(), ThumbnailURL: v.ThumbnailURL(), Extra: e.Extra}
+				if normalised.Source == e.Source && normalised.VideoType == e.VideoType &&
+					normalised.EmbedURL == e.EmbedURL && normalised.ThumbnailURL == e.ThumbnailURL {
+					return
+				}
+				switch {
+				case e.Source != normalised.Source:
+					changes = append(changes, fmt.Sprintf("%s: %s video %s normalised to %s", path, v.Provider, e.Source, v.ID))
+				case e.VideoType != normalised.VideoType:
+					changes = append(changes, fmt.Sprintf("%s: videoType %q set to %s", path, e.VideoType, v.Provider))
+				default:
+					changes = append(changes, fmt.Sprintf("%s: %s video embed and thumbnail URLs set", path, v.Provider))
+				}
+				*e = normalised
+				changed = true
+			})
+			if !changed {
+				return data, changes, nil
+			}
+			encoded, err := model.EncodeBody(body)
+			if err != nil {
+				return nil, nil, err
+			}
+			processed, err := setField(data, "body", encoded)
+			if err != nil {
+				return nil, nil, err
+			}
+			return processed, changes, nil
+		},
+	}
+}
+
+// setField sets top level field of the mitem leaving all the other fields untouched
+func setField(data json.RawMessage, name string, value interface{}) (json.RawMessage, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, fmt.Errorf("Unable to unmarshal passed mitem, error = %s", err.Error())
+	}
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	fields[name] = encoded
+	return json.Marshal(fields)
+}
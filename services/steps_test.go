@@ -0,0 +1,72 @@
+package services
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/jedynykaban/testkeyholder/images"
+	"github.com/jedynykaban/testkeyholder/sanitize"
+)
+
+// bodyWithExtras has an element for every step to change along with the fields and elements we do not model
+const bodyWithExtras = `{
+	"sourceURL": "https://www.svt.se/nyheter/a",
+	"body": [
+		{"type": "paragraph", "content": "<b onclick=\"x()\">Lead</b>", "style": "lead"},
+		{"type": "video", "source": "https://youtu.be/dQw4w9WgXcQ", "videoType": "YouTube", "autoplay": true},
+		{"type": "image", "source": "http://www.svt.se/a.jpg", "width": 1280, "height": 720, "credit": "TT"},
+		{"type": "quote", "content": "<script>x()</script>", "author": "Someone"},
+		{"type": "gallery", "layout": "grid", "body": [
+			{"type": "image", "source": "/b.jpg", "credit": "AP"}
+		]}
+	]
+}`
+
+func TestStepsKeepUnknownFields(t *testing.T) {
+	steps := []ProcessStep{
+		SanitiseHTMLStep(sanitize.DefaultPolicy),
+		NormaliseVideoStep(),
+		NormaliseImagesStep(images.DefaultOptions),
+	}
+	for _, step := range steps {
+		processed, changes, err := step.Func(json.RawMessage(bodyWithExtras))
+		if err != nil {
+			t.Fatalf("%s: unexpected error = %v", step.Name, err)
+		}
+		if len(changes) == 0 {
+			t.Errorf("%s: no changes reported", step.Name)
+		}
+		var mitem struct {
+			Body []map[string]interface{} `json:"body"`
+		}
+		if err := json.Unmarshal(processed, &mitem); err != nil {
+			t.Fatalf("%s: unable to unmarshal processed mitem, error = %v", step.Name, err)
+		}
+		if len(mitem.Body) != 5 {
+			t.Fatalf("%s: processed body has %d elements, want = 5", step.Name, len(mitem.Body))
+		}
+		gallery, _ := mitem.Body[4]["body"].([]interface{})
+		var galleryImage map[string]interface{}
+		if len(gallery) == 1 {
+			galleryImage, _ = gallery[0].(map[string]interface{})
+		}
+		kept := []struct {
+			element map[string]interface{}
+			field   string
+			want    interface{}
+		}{
+			{mitem.Body[0], "style", "lead"},
+			{mitem.Body[1], "autoplay", true},
+			{mitem.Body[2], "credit", "TT"},
+			{mitem.Body[3], "content", "<script>x()</script>"},
+			{mitem.Body[3], "author", "Someone"},
+			{mitem.Body[4], "layout", "grid"},
+			{galleryImage, "credit", "AP"},
+		}
+		for _, k := range kept {
+			if got := k.element[k.field]; got != k.want {
+				t.Errorf("%s: field %s got = %v, want = %v", step.Name, k.field, got, k.want)
+			}
+		}
+	}
+}
@@ -0,0 +1,169 @@
+// Package slug makes URL slugs out of mitem headlines.
+package slug
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// DefaultMaxLength is the slug length limit used when none is given
+const DefaultMaxLength = 80
+
+// DefaultMaxAttempts is how many suffixed slugs are tried before giving up
+const DefaultMaxAttempts = 100
+
+// Languages with transliteration rules different from the default ones
+const (
+	// LanguageGerman transliterates umlauts as ae, oe, ue
+	LanguageGerman = "de"
+)
+
+// ErrNoUniqueSlug is returned when all the attempts to find a free slug failed
+var ErrNoUniqueSlug = errors.New("Unable to find unique slug")
+
+// transliterations hold the default rules, Nordic letters follow the Swedish convention (ä -> a)
+var transliterations = map[rune]string{
+	'å': "a", 'ä': "a", 'ö': "o", 'æ': "ae", 'ø': "o", 'ü': "u", 'ß': "ss",
+	'à': "a", 'á': "a", 'â': "a", 'ã': "a", 'ā': "a", 'ą': "a",
+	'ç': "c", 'ć': "c", 'č': "c", 'ď': "d", 'đ': "d", 'ð': "d",
+	'è': "e", 'é': "e", 'ê': "e", 'ë': "e", 'ē': "e", 'ě': "e", 'ę': "e",
+	'ì': "i", 'í': "i", 'î': "i", 'ï': "i", 'ī': "i",
+	'ł': "l", 'ñ': "n", 'ń': "n", 'ň': "n",
+	'ò': "o", 'ó': "o", 'ô': "o", 'õ': "o", 'ő': "o", 'ō': "o", 'œ': "oe",
+	'ř': "r", 'š': "s", 'ś': "s", 'ť': "t", 'þ': "th",
+	'ù': "u", 'ú': "u", 'û': "u", 'ů': "u", 'ű': "u", 'ū': "u",
+	'ý': "y", 'ÿ': "y", 'ž': "z", 'ź': "z", 'ż': "z",
+}
+
+var languageTransliterations = map[string]map[rune]string{
+	LanguageGerman: {'ä': "ae", 'ö': "oe", 'ü': "ue"},
+}
+
+// apostrophes are dropped instead of being turned into separators, so "Anna's" becomes "annas"
+var apostrophes = strings.NewReplacer("'", "", "’", "", "`", "")
+
+var separators = regexp.MustCompile(`[^a-z0-9]+`)
+
+// Make makes the slug of s with the default transliteration rules and no length limit
+func Make(s string) string {
+	return MakeLang(s, "")
+}
+
+// MakeLang makes the slug of s with the transliteration rules of the language
+func MakeLang(s string, language string) string {
+	overrides := languageTransliterations[language]
+	var b strings.Builder
+	for _, r := range strings.ToLower(apostrophes.Replace(s)) {
+		if t, ok := overrides[r]; ok {
+			b.WriteString(t)
+			continue
+		}
+		if t, ok := transliterations[r]; ok {
+			b.WriteString(t)
+			continue
+		}
+		if r < utf8.RuneSelf && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			b.WriteRune(r)
+			continue
+		}
+		// anything else, including letters we cannot transliterate, separates words
+		b.WriteByte('-')
+	}
+	return strings.Trim(separators.ReplaceAllString(b.String(), "-"), "-")
+}
+
+// Truncate cuts the slug to max bytes at a word boundary.
+// A single word longer than max is cut in the middle.
+func Truncate(slug string, max int) string {
+	if max <= 0 || len(slug) <= max {
+		return slug
+	}
+	cut := slug[:max]
+	if slug[max] != '-' {
+		if idx := strings.LastIndex(cut, "-"); idx > 0 {
+			cut = cut[:idx]
+		}
+	}
+	return strings.Trim(cut, "-")
+}
+
+// UniquenessChecker tells whether the slug is already taken
+type UniquenessChecker interface {
+	SlugExists(ctx context.Context, slug string) (bool, error)
+}
+
+// CheckerFunc is an adapter allowing one to use a function as UniquenessChecker
+type CheckerFunc func(ctx context.Context, slug string) (bool, error)
+
+// SlugExists implements UniquenessChecker
+func (f CheckerFunc) SlugExists(ctx context.Context, slug string) (bool, error) {
+	return f(ctx, slug)
+}
+
+// Generator makes unique slugs of limited length
+type Generator struct {
+	// MaxLength limits the slug length, suffixes included, 0 means no limit.
+	// It has to leave room for at least one character besides the suffix.
+	MaxLength int
+	// MaxAttempts limits the number of slugs tried, the unsuffixed one included,
+	// 0 means DefaultMaxAttempts
+	MaxAttempts int
+	// Language selects the transliteration rules, empty means the default ones
+	Language string
+	// Checker tells whether a slug is taken, nil means every slug is unique
+	Checker UniquenessChecker
+}
+
+// NewGenerator - ctor like function - creates a generator with the default limits
+func NewGenerator(checker UniquenessChecker) *Generator {
+	return &Generator{
+		MaxLength:   DefaultMaxLength,
+		MaxAttempts: DefaultMaxAttempts,
+		Checker:     checker,
+	}
+}
+
+// Generate makes the slug of the headline. When the slug is taken,
+// -2, -3 and so on are appended, shortening the slug to fit the length limit.
+func (g *Generator) Generate(ctx context.Context, headline string) (string, error) {
+	base := MakeLang(headline, g.Language)
+	if len(base) == 0 {
+		return "", fmt.Errorf("Unable to make slug of %q", headline)
+	}
+	candidate := Truncate(base, g.MaxLength)
+	if g.Checker == nil {
+		return candidate, nil
+	}
+	maxAttempts := g.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = DefaultMaxAttempts
+	}
+	// the first attempt checks the unsuffixed slug
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		if attempt > 1 {
+			suffix := "-" + strconv.Itoa(attempt)
+			max := 0
+			if g.MaxLength > 0 {
+				// Truncate takes the non positive length for no limit, the suffixed slug would not fit then
+				if max = g.MaxLength - len(suffix); max <= 0 {
+					return "", fmt.Errorf("Slug length limit %d leaves no room besides suffix %s", g.MaxLength, suffix)
+				}
+			}
+			candidate = Truncate(base, max) + suffix
+		}
+		exists, err := g.Checker.SlugExists(ctx, candidate)
+		if err != nil {
+			return "", err
+		}
+		if !exists {
+			return candidate, nil
+		}
+	}
+	return "", ErrNoUniqueSlug
+}
@@ -0,0 +1,104 @@
+package slug
+
+import (
+	"context"
+	"testing"
+)
+
+func TestMake(t *testing.T) {
+	tests := []struct {
+		in       string
+		language string
+		want     string
+	}{
+		{"Hello, World!", "", "hello-world"},
+		{"Räksmörgås på Åland", "", "raksmorgas-pa-aland"},
+		{"Grüße aus München", LanguageGerman, "gruesse-aus-muenchen"},
+		{"Anna's café — 2020", "", "annas-cafe-2020"},
+		{"  --Ærø øl--  ", "", "aero-ol"},
+		{"日本語", "", ""},
+	}
+	for _, tt := range tests {
+		if got := MakeLang(tt.in, tt.language); got != tt.want {
+			t.Errorf("MakeLang(%q, %q) got = %q, want = %q", tt.in, tt.language, got, tt.want)
+		}
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		slug string
+		max  int
+		want string
+	}{
+		{"hello-world", 0, "hello-world"},
+		{"hello-world", 11, "hello-world"},
+		{"hello-world", 8, "hello"},
+		{"hello-world", 6, "hello"},
+		{"supercalifragilistic", 5, "super"},
+	}
+	for _, tt := range tests {
+		if got := Truncate(tt.slug, tt.max); got != tt.want {
+			t.Errorf("Truncate(%q, %d) got = %q, want = %q", tt.slug, tt.max, got, tt.want)
+		}
+	}
+}
+
+// taken makes the checker reporting the slugs as taken
+func taken(slugs ...string) UniquenessChecker {
+	return CheckerFunc(func(ctx context.Context, slug string) (bool, error) {
+		for _, s := range slugs {
+			if s == slug {
+				return true, nil
+			}
+		}
+		return false, nil
+	})
+}
+
+func TestGenerate(t *testing.T) {
+	tests := []struct {
+		name      string
+		maxLength int
+		checker   UniquenessChecker
+		want      string
+		wantErr   bool
+	}{
+		{"free", 80, taken(), "hello-world", false},
+		{"suffixed", 80, taken("hello-world", "hello-world-2"), "hello-world-3", false},
+		{"suffixed within the limit", 11, taken("hello-world"), "hello-2", false},
+		{"no limit", 0, taken("hello-world"), "hello-world-2", false},
+		{"limit shorter than the suffix", 1, taken("h"), "", true},
+		{"limit equal to the suffix", 2, taken("he"), "", true},
+	}
+	for _, tt := range tests {
+		g := NewGenerator(tt.checker)
+		g.MaxLength = tt.maxLength
+		got, err := g.Generate(context.Background(), "Hello World")
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: Generate error = %v, wantErr = %t", tt.name, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s: Generate got = %q, want = %q", tt.name, got, tt.want)
+		}
+		if tt.maxLength > 0 && len(got) > tt.maxLength {
+			t.Errorf("%s: Generate got = %q longer than %d", tt.name, got, tt.maxLength)
+		}
+	}
+
+	g := NewGenerator(taken("hello-world"))
+	g.MaxAttempts = 1
+	if _, err := g.Generate(context.Background(), "Hello World"); err != ErrNoUniqueSlug {
+		t.Errorf("Generate with no attempts left error = %v, want = %v", err, ErrNoUniqueSlug)
+	}
+
+	zero := &Generator{Checker: taken("hello-world")}
+	if got, err := zero.Generate(context.Background(), "Hello World"); err != nil || got != "hello-world-2" {
+		t.Errorf("Generate with zero value limits got = %q, %v, want = %q, <nil>", got, err, "hello-world-2")
+	}
+	zero = &Generator{Checker: taken()}
+	if got, err := zero.Generate(context.Background(), "Hello World"); err != nil || got != "hello-world" {
+		t.Errorf("Generate with zero value limits got = %q, %v, want = %q, <nil>", got, err, "hello-world")
+	}
+}
@@ -0,0 +1,304 @@
+// Package video reads the videos embedded in the mitems: it normalises the source,
+// which publishers send either as the provider's video ID or as any of its URL forms,
+// and makes the embed and thumbnail URLs.
+package video
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Providers we know about, the names are the videoType values
+const (
+	ProviderYoutube     = "youtube"
+	ProviderVimeo       = "vimeo"
+	ProviderDailymotion = "dailymotion"
+	ProviderJWPlayer    = "jwplayer"
+	// ProviderMP4 and ProviderHLS are plain files, their ID is the URL of the file
+	ProviderMP4 = "mp4"
+	ProviderHLS = "hls"
+)
+
+// ErrUnsupportedProvider is returned for the videoType none of the providers registered for
+var ErrUnsupportedProvider = errors.New("Unsupported video provider")
+
+// Video is the normalised video
+type Video struct {
+	Provider string `json:"provider"`
+	ID       string `json:"id"`
+}
+
+// Provider knows the IDs and URLs of a video service
+type Provider struct {
+	Name string
+	// ID reads the video ID out of the URL, it reports false for the URLs of other providers
+	ID func(u *url.URL) (string, bool)
+	// ValidID tells whether the source is a bare video ID, nil means bare IDs are not accepted
+	ValidID func(id string) bool
+	// Embed makes the player URL
+	Embed func(id string) string
+	// Thumbnail makes the thumbnail URL, nil when the provider has none without an API call
+	Thumbnail func(id string) string
+}
+
+var (
+	mu        sync.RWMutex
+	providers = make(map[string]Provider)
+)
+
+// Register adds the provider replacing the one of the same name
+func Register(p Provider) {
+	mu.Lock()
+	defer mu.Unlock()
+	providers[p.Name] = p
+}
+
+// Providers returns sorted names of the registered providers
+func Providers() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+	ret := make([]string, 0, len(providers))
+	for name := range providers {
+		ret = append(ret, name)
+	}
+	sort.Strings(ret)
+	return ret
+}
+
+// IsSupported tells whether the provider is registered
+func IsSupported(provider string) bool {
+	_, ok := lookup(provider)
+	return ok
+}
+
+func lookup(name string) (Provider, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+	p, ok := providers[strings.ToLower(name)]
+	return p, ok
+}
+
+// Parse normalises the source of the video of the given type.
+// The source is either the provider's video ID or any of the provider's URL forms.
+// When videoType is empty the provider is detected from the source URL.
+func Parse(videoType, source string) (Video, error) {
+	source = strings.TrimSpace(source)
+	if len(videoType) == 0 {
+		if v, ok := Detect(source); ok {
+			return v, nil
+		}
+		return Video{}, fmt.Errorf("Unable to detect video provider of %s", source)
+	}
+	p, ok := lookup(videoType)
+	if !ok {
+		return Video{}, ErrUnsupportedProvider
+	}
+	if u, ok := absoluteURL(source); ok {
+		if id, ok := p.ID(u); ok {
+			return Video{Provider: p.Name, ID: id}, nil
+		}
+	} else if p.ValidID != nil && p.ValidID(source) {
+		return Video{Provider: p.Name, ID: source}, nil
+	}
+	return Video{}, fmt.Errorf("Unable to read %s video ID from %s", p.Name, source)
+}
+
+// Detect finds the provider of the video URL, bare IDs cannot be detected
+func Detect(source string) (Video, bool) {
+	u, ok := absoluteURL(strings.TrimSpace(source))
+	if !ok {
+		return Video{}, false
+	}
+	for _, name := range Providers() {
+		p, _ := lookup(name)
+		if id, ok := p.ID(u); ok {
+			return Video{Provider: p.Name, ID: id}, true
+		}
+	}
+	return Video{}, false
+}
+
+// EmbedURL returns the player URL, the file URL for plain files
+func (v Video) EmbedURL() string {
+	p, ok := lookup(v.Provider)
+	if !ok {
+		return ""
+	}
+	return p.Embed(v.ID)
+}
+
+// ThumbnailURL returns the thumbnail URL, empty when the provider has none
+func (v Video) ThumbnailURL() string {
+	p, ok := lookup(v.Provider)
+	if !ok || p.Thumbnail == nil {
+		return ""
+	}
+	return p.Thumbnail(v.ID)
+}
+
+// IsFile tells whether the video is a plain file rather than a player of a video service
+func (v Video) IsFile() bool {
+	return v.Provider == ProviderMP4 || v.Provider == ProviderHLS
+}
+
+func absoluteURL(source string) (*url.URL, bool) {
+	if strings.HasPrefix(source, "//") {
+		source = "https:" + source
+	}
+	u, err := url.Parse(source)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || len(u.Host) == 0 {
+		return nil, false
+	}
+	return u, true
+}
+
+// host returns the lowercased host without the www. and m. prefixes
+func host(u *url.URL) string {
+	h := strings.ToLower(u.Hostname())
+	h = strings.TrimPrefix(h, "www.")
+	return strings.TrimPrefix(h, "m.")
+}
+
+// segments splits the URL path dropping the empty segments
+func segments(u *url.URL) []string {
+	var ret []string
+	for _, s := range strings.Split(u.Path, "/") {
+		if len(s) > 0 {
+			ret = append(ret, s)
+		}
+	}
+	return ret
+}
+
+func matcher(expr string) func(id string) bool {
+	return regexp.MustCompile(expr).MatchString
+}
+
+var (
+	youtubeID     = matcher(`^[A-Za-z0-9_-]{11}$`)
+	vimeoID       = matcher(`^[0-9]+$`)
+	dailymotionID = matcher(`^[a-zA-Z0-9]{5,12}$`)
+	jwplayerID    = matcher(`^[A-Za-z0-9]{8}(-[A-Za-z0-9]{8})?$`)
+)
+
+func init() {
+	Register(Provider{
+		Name:    ProviderYoutube,
+		ValidID: youtubeID,
+		ID: func(u *url.URL) (string, bool) {
+			var id string
+			s := segments(u)
+			switch host(u) {
+			case "youtu.be":
+				if len(s) > 0 {
+					id = s[0]
+				}
+			case "youtube.com", "youtube-nocookie.com", "music.youtube.com":
+				if v := u.Query().Get("v"); len(v) > 0 {
+					id = v
+				} else if len(s) == 2 && (s[0] == "embed" || s[0] == "shorts" || s[0] == "v" || s[0] == "live") {
+					id = s[1]
+				}
+			}
+			return id, youtubeID(id)
+		},
+		Embed: func(id string) string { return "https://www.youtube.com/embed/" + id },
+		Thumbnail: func(id string) string {
+			return "https://i.ytimg.com/vi/" + id + "/hqdefault.jpg"
+		},
+	})
+	Register(Provider{
+		Name:    ProviderVimeo,
+		ValidID: vimeoID,
+		ID: func(u *url.URL) (string, bool) {
+			h := host(u)
+			if h != "vimeo.com" && h != "player.vimeo.com" {
+				return "", false
+			}
+			// vimeo.com/123, vimeo.com/channels/staffpicks/123, player.vimeo.com/video/123
+			s := segments(u)
+			for idx := len(s) - 1; idx >= 0; idx-- {
+				if vimeoID(s[idx]) {
+					return s[idx], true
+				}
+			}
+			return "", false
+		},
+		Embed: func(id string) string { return "https://player.vimeo.com/video/" + id },
+	})
+	Register(Provider{
+		Name:    ProviderDailymotion,
+		ValidID: dailymotionID,
+		ID: func(u *url.URL) (string, bool) {
+			var id string
+			s := segments(u)
+			switch host(u) {
+			case "dai.ly":
+				if len(s) > 0 {
+					id = s[0]
+				}
+			case "dailymotion.com", "geo.dailymotion.com":
+				if v := u.Query().Get("video"); len(v) > 0 {
+					id = v
+				} else if len(s) >= 2 && s[len(s)-2] == "video" {
+					id = s[len(s)-1]
+				}
+			}
+			// dailymotion.com/video/x7tgad0_title-slug
+			id = strings.SplitN(id, "_", 2)[0]
+			return id, dailymotionID(id)
+		},
+		Embed: func(id string) string { return "https://www.dailymotion.com/embed/video/" + id },
+		Thumbnail: func(id string) string {
+			return "https://www.dailymotion.com/thumbnail/video/" + id
+		},
+	})
+	Register(Provider{
+		Name:    ProviderJWPlayer,
+		ValidID: jwplayerID,
+		ID: func(u *url.URL) (string, bool) {
+			h := host(u)
+			if h != "cdn.jwplayer.com" && h != "content.jwplatform.com" {
+				return "", false
+			}
+			// cdn.jwplayer.com/v2/media/MEDIAID, cdn.jwplayer.com/players/MEDIAID-PLAYERID.html,
+			// content.jwplatform.com/videos/MEDIAID-PLAYERID.mp4
+			s := segments(u)
+			if len(s) == 0 {
+				return "", false
+			}
+			id := s[len(s)-1]
+			id = strings.TrimSuffix(id, path.Ext(id))
+			return id, jwplayerID(id)
+		},
+		Embed: func(id string) string { return "https://cdn.jwplayer.com/players/" + id + ".html" },
+		Thumbnail: func(id string) string {
+			return "https://cdn.jwplayer.com/v2/media/" + strings.SplitN(id, "-", 2)[0] + "/poster.jpg"
+		},
+	})
+	Register(fileProvider(ProviderMP4, ".mp4", ".m4v"))
+	Register(fileProvider(ProviderHLS, ".m3u8"))
+}
+
+// fileProvider accepts the URLs of the files with the extensions, the ID is the URL itself
+func fileProvider(name string, extensions ...string) Provider {
+	return Provider{
+		Name: name,
+		ID: func(u *url.URL) (string, bool) {
+			ext := strings.ToLower(path.Ext(u.Path))
+			for _, e := range extensions {
+				if ext == e {
+					return u.String(), true
+				}
+			}
+			return "", false
+		},
+		Embed: func(id string) string { return id },
+	}
+}
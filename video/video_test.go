@@ -0,0 +1,55 @@
+package video
+
+import "testing"
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		videoType string
+		source    string
+		want      Video
+		wantErr   bool
+	}{
+		{"youtube", "dQw4w9WgXcQ", Video{ProviderYoutube, "dQw4w9WgXcQ"}, false},
+		{"YouTube", "https://www.youtube.com/watch?v=dQw4w9WgXcQ&t=42", Video{ProviderYoutube, "dQw4w9WgXcQ"}, false},
+		{"youtube", "https://youtu.be/dQw4w9WgXcQ", Video{ProviderYoutube, "dQw4w9WgXcQ"}, false},
+		{"youtube", "https://www.youtube.com/embed/dQw4w9WgXcQ", Video{ProviderYoutube, "dQw4w9WgXcQ"}, false},
+		{"youtube", "https://www.youtube.com/shorts/dQw4w9WgXcQ", Video{ProviderYoutube, "dQw4w9WgXcQ"}, false},
+		{"vimeo", "https://vimeo.com/channels/staffpicks/76979871", Video{ProviderVimeo, "76979871"}, false},
+		{"vimeo", "https://player.vimeo.com/video/76979871", Video{ProviderVimeo, "76979871"}, false},
+		{"dailymotion", "https://www.dailymotion.com/video/x7tgad0_some-title", Video{ProviderDailymotion, "x7tgad0"}, false},
+		{"dailymotion", "https://dai.ly/x7tgad0", Video{ProviderDailymotion, "x7tgad0"}, false},
+		{"mp4", "https://cdn.example.com/clip.MP4", Video{ProviderMP4, "https://cdn.example.com/clip.MP4"}, false},
+		{"", "https://youtu.be/dQw4w9WgXcQ", Video{ProviderYoutube, "dQw4w9WgXcQ"}, false},
+		{"", "dQw4w9WgXcQ", Video{}, true},
+		{"youtube", "https://vimeo.com/76979871", Video{}, true},
+		{"youtube", "not an id", Video{}, true},
+		{"realplayer", "abc", Video{}, true},
+	}
+	for _, tt := range tests {
+		got, err := Parse(tt.videoType, tt.source)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("Parse(%q, %q) error = %v, wantErr = %t", tt.videoType, tt.source, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Parse(%q, %q) got = %+v, want = %+v", tt.videoType, tt.source, got, tt.want)
+		}
+	}
+
+	if _, err := Parse("realplayer", "abc"); err != ErrUnsupportedProvider {
+		t.Errorf("Parse of unknown provider error = %v, want = %v", err, ErrUnsupportedProvider)
+	}
+}
+
+func TestURLs(t *testing.T) {
+	v := Video{Provider: ProviderYoutube, ID: "dQw4w9WgXcQ"}
+	if got, want := v.EmbedURL(), "https://www.youtube.com/embed/dQw4w9WgXcQ"; got != want {
+		t.Errorf("EmbedURL got = %s, want = %s", got, want)
+	}
+	if got, want := v.ThumbnailURL(), "https://i.ytimg.com/vi/dQw4w9WgXcQ/hqdefault.jpg"; got != want {
+		t.Errorf("ThumbnailURL got = %s, want = %s", got, want)
+	}
+	if got := (Video{Provider: ProviderVimeo, ID: "76979871"}).ThumbnailURL(); got != "" {
+		t.Errorf("Vimeo ThumbnailURL got = %s, want none", got)
+	}
+}